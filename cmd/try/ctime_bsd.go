@@ -0,0 +1,17 @@
+//go:build darwin || freebsd || netbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileCTime returns the birth time recorded in the stat buffer.
+func fileCTime(_ string, fi os.FileInfo) time.Time {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Birthtimespec.Unix())
+	}
+	return fi.ModTime()
+}
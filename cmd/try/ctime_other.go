@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// fileCTime falls back to the mtime where no creation time is available.
+func fileCTime(_ string, fi os.FileInfo) time.Time {
+	return fi.ModTime()
+}
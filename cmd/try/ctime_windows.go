@@ -0,0 +1,17 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileCTime returns the creation time from the file attribute data.
+func fileCTime(_ string, fi os.FileInfo) time.Time {
+	if d, ok := fi.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, d.CreationTime.Nanoseconds())
+	}
+	return fi.ModTime()
+}
@@ -1,32 +1,86 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
+	"unicode"
 
+	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
 	version       = "1.8.2"
 	scriptWarning = "# if you can read this, you didn't launch try from an alias. run try --help."
+
+	// historyMaxVisits caps how many visit timestamps are kept per try.
+	historyMaxVisits = 50
+	// historyHalfLife is how long it takes a visit to lose half its weight.
+	historyHalfLife = 7 * 24 * time.Hour
+
+	// The preview pane lists at most previewTreeEntries directory entries and
+	// previewReadmeLines lines of a README or NOTES file, and only opens when
+	// the terminal is at least previewMinWidth columns wide.
+	previewTreeEntries = 12
+	previewReadmeLines = 8
+	previewMinWidth    = 60
+
+	// gitWorkers bounds how many git inspections run at once.
+	gitWorkers = 8
+	// duWorkers bounds the extra goroutines walking directories for sizes.
+	duWorkers = 16
+
+	trashDirName     = ".trash"
+	trashStampLayout = "20060102-150405"
+
+	// archiveDirName holds compressed tries, one <name>.tar.gz each.
+	archiveDirName = ".archive"
+	archiveExt     = ".tar.gz"
 )
 
+// archiveExts are the archive formats try unarchive recognises; tar detects
+// the compression itself when extracting.
+var archiveExts = []string{".tar.gz", ".tgz", ".tar.zst"}
+
+// keyNames lists the configurable selector actions in display order.
+var keyNames = []string{"up", "down", "page_up", "page_down", "home", "end", "enter", "mark", "mark_all", "delete", "rename", "archive", "tag", "pin", "preview", "sizes", "next_root", "cancel"}
+
+// hookNames lists the events a [hooks] command can be attached to.
+var hookNames = []string{"on_create", "on_clone", "on_enter", "on_delete"}
+
+// cfg holds the effective configuration. run replaces it with loadConfig's
+// result; tests see the defaults.
+var cfg = defaultConfig()
+
+// directCommands print their output for the user instead of a script for
+// the shell wrapper to eval, so the wrapper runs them straight through.
+var directCommands = []string{"list", "config", "cache", "du", "pin", "unpin", "tag", "describe"}
+
 var (
-	httpsGitURIRe = regexp.MustCompile(`^https?://([^/]+)/([^/]+)/([^/]+)$`)
-	sshGitURIRe   = regexp.MustCompile(`^git@([^:]+):([^/]+)/([^/]+)$`)
+	gitPathRe  = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)+$`)
+	sshAliasRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
 
 	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
 	subtleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
@@ -35,13 +89,70 @@ var (
 	dangerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
 	promptStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
 	confirmStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("213")).Bold(true)
+	matchStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	cursorStyle  = lipgloss.NewStyle().Reverse(true)
+	pinStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+	gitStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
 )
 
 type entry struct {
-	Name    string
-	Path    string
-	Created time.Time
-	Touched time.Time
+	Name     string
+	Path     string
+	Root     string
+	Created  time.Time
+	Touched  time.Time
+	Frecency float64
+	Pinned   bool
+	// Tags, Description and Template come from the metadata store.
+	Tags        []string
+	Description string
+	Template    string
+}
+
+// history records when each try was selected, keyed by absolute path.
+type history struct {
+	Visits map[string][]time.Time `json:"visits"`
+}
+
+// metadata holds per-try settings kept outside the try itself, keyed by
+// absolute path like history.
+type metadata struct {
+	Tries map[string]*tryMeta `json:"tries"`
+}
+
+type tryMeta struct {
+	Pinned      bool     `json:"pinned,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// Template is the template a new try was created with. Where a clone
+	// came from is read back from its origin remote instead.
+	Template string `json:"template,omitempty"`
+}
+
+func (t *tryMeta) empty() bool {
+	return !t.Pinned && len(t.Tags) == 0 && t.Description == "" && t.Template == ""
+}
+
+// addTags adds tags not already present, keeping them sorted.
+func (t *tryMeta) addTags(tags ...string) {
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" && !slices.Contains(t.Tags, tag) {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	slices.Sort(t.Tags)
+}
+
+func (t *tryMeta) removeTags(tags ...string) {
+	t.Tags = slices.DeleteFunc(t.Tags, func(tag string) bool {
+		return slices.ContainsFunc(tags, func(rm string) bool { return normalizeTag(rm) == tag })
+	})
+}
+
+// normalizeTag lowercases a tag and strips a leading "#" or "tag:".
+func normalizeTag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "tag:")
+	return strings.ToLower(strings.Join(strings.Fields(tag), cfg.Separator))
 }
 
 type scoredEntry struct {
@@ -52,36 +163,246 @@ type scoredEntry struct {
 
 type gitURI struct {
 	Host string
+	Port string
+	// User is the owner, or the full group path for nested GitLab groups.
 	User string
 	Repo string
+	// URL is what gets handed to git clone: the input with web UI suffixes
+	// stripped and shorthands expanded.
+	URL string
+	// Ref and RefName are set for pull/merge request links: the ref to fetch
+	// and the short name ("pr123", "mr45") used for the branch and directory.
+	Ref     string
+	RefName string
+}
+
+// cloneValueFlags and cloneBoolFlags are the git clone options try clone
+// passes through.
+var (
+	cloneValueFlags = []string{"--branch", "-b", "--depth", "--filter"}
+	cloneBoolFlags  = []string{"--recurse-submodules", "--shallow-submodules", "--single-branch", "--no-single-branch", "--sparse"}
+)
+
+// gitShorthandHosts expands "gh:user/repo" style prefixes.
+var gitShorthandHosts = map[string]string{"gh": "github.com", "gl": "gitlab.com"}
+
+// gitWebMarkers start the web UI part of a forge URL, as in
+// https://github.com/user/repo/tree/main.
+var gitWebMarkers = []string{"-", "tree", "blob", "commit", "commits", "pull", "pulls", "merge_requests", "issues", "releases", "actions", "wiki", "compare", "tags", "branches", "src"}
+
+type scoreWeights struct {
+	DatePrefix float64
+	Created    float64
+	Touched    float64
+	Frecency   float64
+}
+
+// gcPolicy is the [gc] table: the default age for try gc and what happens
+// to candidates. An empty UntouchedFor leaves the policy off.
+type gcPolicy struct {
+	UntouchedFor string
+	// Action is "trash" or "archive".
+	Action string
+}
+
+type config struct {
+	// Roots lists the tries directories; new tries go into the first one.
+	Roots      []string
+	DateFormat string
+	Separator  string
+	// DefaultHost resolves "user/repo" clone shorthands.
+	DefaultHost string
+	// Mirror keeps bare mirrors of cloned repos under cacheDir so repeated
+	// clones only fetch what changed.
+	Mirror bool
+	// GitStatus shows branch, ahead/behind and dirty counts in the selector.
+	GitStatus bool
+	Keys      map[string][]string
+	Theme     map[string]string
+	Score     scoreWeights
+	// Hooks maps an event from hookNames to a shell command.
+	Hooks map[string]string
+	GC    gcPolicy
+	// sources records where each setting came from: default, config, env or flag.
+	sources map[string]string
+}
+
+// fileConfig mirrors config.toml. Pointers and maps tell unset keys apart
+// from zero values so only what the file sets overrides the defaults.
+type fileConfig struct {
+	Path        any                 `toml:"path"`
+	DateFormat  *string             `toml:"date_format"`
+	Separator   *string             `toml:"separator"`
+	DefaultHost *string             `toml:"default_host"`
+	Mirror      *bool               `toml:"mirror"`
+	GitStatus   *bool               `toml:"git_status"`
+	Keys        map[string][]string `toml:"keys"`
+	Theme       map[string]string   `toml:"theme"`
+	Score       map[string]float64  `toml:"score"`
+	Hooks       map[string]string   `toml:"hooks"`
+	GC          map[string]string   `toml:"gc"`
 }
 
 type selectorModel struct {
-	basePath      string
-	query         string
-	entries       []entry
-	filtered      []scoredEntry
-	cursor        int
-	selected      string
-	deleted       string
-	cancelled     bool
+	basePath    string
+	roots       []string
+	query       string
+	queryCursor int
+	entries     []entry
+	filtered    []scoredEntry
+	cursor      int
+	offset      int
+	selected    string
+	deleted     []string
+	archived    []string
+	cancelled   bool
+	// marked holds the paths picked for a batch action, whether or not the
+	// current query shows them.
+	marked        map[string]bool
 	deleteMode    bool
 	deleteConfirm string
-	deleteTarget  string
-	keys          selectorKeyMap
-	help          help.Model
-	width         int
-	height        int
+	deleteTargets []string
+	// deleteSummaries fill in as loadDeleteSummary reports back; confirming
+	// waits for all of them. archiveMode reuses the confirmation to archive
+	// the targets instead.
+	deleteSummaries map[string]deleteSummary
+	archiveMode     bool
+	hardDelete      bool
+	// restoring picks from trashed or archived tries: no create row and no
+	// actions on entries. prompt replaces the "try » " title.
+	restoring bool
+	prompt    string
+	// notice is an extra status line, e.g. from the gc policy.
+	notice string
+	// previews caches loaded preview panes by path; a nil value marks one
+	// still loading.
+	showPreview bool
+	previews    map[string]*preview
+	// gitInfo fills in as gitUpdates delivers results; nil gitUpdates means
+	// git inspection is off.
+	gitInfo    map[string]gitInfo
+	gitUpdates <-chan gitStatusMsg
+	// showSizes adds a size column and sortBySize orders the list by it;
+	// sizes is nil until measureSizes reports back.
+	showSizes  bool
+	sortBySize bool
+	sizes      map[string]usageTotal
+	sizing     bool
+	// renameMode edits the part of the name after the date prefix, which is
+	// kept as renamePrefix.
+	renameMode   bool
+	renameTarget string
+	renamePrefix string
+	renameInput  string
+	renameCursor int
+	renameErr    string
+	// tagMode edits tags on the marked tries, or the highlighted one:
+	// words add tags, words starting with "-" remove them.
+	tagMode    bool
+	tagTargets []string
+	tagInput   string
+	tagCursor  int
+	// templates are offered after choosing "+ Create new"; templateCursor 0
+	// means no template.
+	templates      []string
+	templateMode   bool
+	templateCursor int
+	template       string
+	createTarget   string
+	createTags     []string
+	created        bool
+	keys           selectorKeyMap
+	help           help.Model
+	width          int
+	height         int
+}
+
+// preview is what the preview pane shows for a try.
+type preview struct {
+	Tree   []string
+	More   int
+	Readme string
+	Lines  []string
+	Branch string
+	Commit string
+	Source string
+	Err    error
+}
+
+// gitInfo summarises the state of a try that is a git checkout.
+type gitInfo struct {
+	Branch     string
+	Upstream   bool
+	Ahead      int
+	Behind     int
+	Dirty      int
+	LastCommit time.Time
+}
+
+// gitStatusMsg streams one inspected try from the git worker pool.
+type gitStatusMsg struct {
+	path string
+	info gitInfo
+}
+
+// deleteSummary describes what deleting a try would destroy.
+type deleteSummary struct {
+	Size  int64
+	Files int
+	// The rest only applies to git checkouts.
+	Git       bool
+	Dirty     int
+	Unpushed  int
+	Stashes   int
+	Worktrees int
+	// WorktreeOf is the repository a linked worktree belongs to.
+	WorktreeOf string
+}
+
+// losesWork reports whether deleting would destroy work that exists nowhere
+// else: uncommitted changes, unpushed commits, stashes, or worktrees that
+// share this repository.
+func (d deleteSummary) losesWork() bool {
+	return d.Dirty > 0 || d.Unpushed > 0 || d.Stashes > 0 || d.Worktrees > 0
+}
+
+type deleteSummaryMsg struct {
+	path    string
+	summary deleteSummary
+}
+
+// sizesMsg delivers the sizes measured by measureSizes, keyed by path.
+type sizesMsg struct {
+	sizes map[string]usageTotal
+}
+
+// previewMsg delivers a preview loaded in the background by loadPreview.
+type previewMsg struct {
+	path    string
+	preview preview
 }
 
 type selectorKeyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Enter   key.Binding
-	Delete  key.Binding
-	Back    key.Binding
-	Confirm key.Binding
-	Cancel  key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Home     key.Binding
+	End      key.Binding
+	Enter    key.Binding
+	Mark     key.Binding
+	MarkAll  key.Binding
+	Delete   key.Binding
+	Rename   key.Binding
+	Archive  key.Binding
+	Tag      key.Binding
+	Pin      key.Binding
+	Preview  key.Binding
+	Sizes    key.Binding
+	NextRoot key.Binding
+	Back     key.Binding
+	Confirm  key.Binding
+	Cancel   key.Binding
 }
 
 func (k selectorKeyMap) ShortHelp() []key.Binding {
@@ -90,28 +411,493 @@ func (k selectorKeyMap) ShortHelp() []key.Binding {
 
 func (k selectorKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Enter},
-		{k.Delete, k.Back, k.Confirm, k.Cancel},
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End, k.Enter},
+		{k.Mark, k.MarkAll, k.Delete, k.Rename, k.Archive, k.Tag, k.Pin, k.Preview, k.Sizes, k.NextRoot, k.Back, k.Confirm, k.Cancel},
 	}
 }
 
 func newSelectorKeyMap() selectorKeyMap {
+	bind := func(name, desc string) key.Binding {
+		keys := cfg.Keys[name]
+		return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyHelp(keys), desc))
+	}
 	return selectorKeyMap{
-		Up:      key.NewBinding(key.WithKeys("up", "ctrl+p"), key.WithHelp("↑/ctrl+p", "up")),
-		Down:    key.NewBinding(key.WithKeys("down", "ctrl+n"), key.WithHelp("↓/ctrl+n", "down")),
-		Enter:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
-		Delete:  key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "delete")),
-		Back:    key.NewBinding(key.WithKeys("backspace"), key.WithHelp("backspace", "erase")),
-		Confirm: key.NewBinding(key.WithKeys("YES"), key.WithHelp("YES", "confirm delete")),
-		Cancel:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+		Up:       bind("up", "up"),
+		Down:     bind("down", "down"),
+		PageUp:   bind("page_up", "page up"),
+		PageDown: bind("page_down", "page down"),
+		Home:     bind("home", "first"),
+		End:      bind("end", "last"),
+		Enter:    bind("enter", "select"),
+		Mark:     bind("mark", "mark"),
+		MarkAll:  bind("mark_all", "mark all"),
+		Delete:   bind("delete", "delete"),
+		Rename:   bind("rename", "rename"),
+		Archive:  bind("archive", "archive"),
+		Tag:      bind("tag", "tag"),
+		Pin:      bind("pin", "pin/unpin"),
+		Preview:  bind("preview", "preview"),
+		Sizes:    bind("sizes", "sizes"),
+		NextRoot: bind("next_root", "next root"),
+		Back:     key.NewBinding(key.WithKeys("backspace"), key.WithHelp("backspace", "erase")),
+		Confirm:  key.NewBinding(key.WithKeys("YES"), key.WithHelp("YES", "confirm delete")),
+		Cancel:   bind("cancel", "cancel"),
+	}
+}
+
+func keyHelp(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case "up":
+			labels[i] = "↑"
+		case "down":
+			labels[i] = "↓"
+		case "pgdown":
+			labels[i] = "pgdn"
+		default:
+			labels[i] = k
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+func defaultConfig() config {
+	return config{
+		Roots:       []string{mustExpand("~/src/tries")},
+		DateFormat:  "2006-01-02",
+		Separator:   "-",
+		DefaultHost: "github.com",
+		Mirror:      true,
+		GitStatus:   true,
+		Keys: map[string][]string{
+			"up":        {"up", "ctrl+p"},
+			"down":      {"down", "ctrl+n"},
+			"page_up":   {"pgup"},
+			"page_down": {"pgdown"},
+			"home":      {"home"},
+			"end":       {"end"},
+			"enter":     {"enter"},
+			"mark":      {"tab"},
+			"mark_all":  {"ctrl+a"},
+			"delete":    {"ctrl+d"},
+			"rename":    {"ctrl+r"},
+			"archive":   {"ctrl+x"},
+			"tag":       {"ctrl+g"},
+			"pin":       {"ctrl+s"},
+			"preview":   {"ctrl+o"},
+			"sizes":     {"ctrl+l"},
+			"next_root": {"ctrl+t"},
+			"cancel":    {"esc"},
+		},
+		Theme:   currentTheme(),
+		Score:   scoreWeights{DatePrefix: 2, Created: 2, Touched: 3, Frecency: 1},
+		Hooks:   map[string]string{},
+		GC:      gcPolicy{Action: "trash"},
+		sources: map[string]string{},
+	}
+}
+
+func themeStyles() map[string]*lipgloss.Style {
+	return map[string]*lipgloss.Style{
+		"title":   &titleStyle,
+		"subtle":  &subtleStyle,
+		"select":  &selectStyle,
+		"create":  &createStyle,
+		"danger":  &dangerStyle,
+		"prompt":  &promptStyle,
+		"confirm": &confirmStyle,
+		"match":   &matchStyle,
+		"git":     &gitStyle,
+	}
+}
+
+func currentTheme() map[string]string {
+	theme := map[string]string{}
+	for name, st := range themeStyles() {
+		theme[name] = fmt.Sprint(st.GetForeground())
+	}
+	return theme
+}
+
+func applyTheme(theme map[string]string) {
+	styles := themeStyles()
+	for name, color := range theme {
+		if st, ok := styles[name]; ok {
+			*st = st.Foreground(lipgloss.Color(color))
+		}
+	}
+}
+
+func configPath() string {
+	if v := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); v != "" {
+		return filepath.Join(mustExpand(v), "try", "config.toml")
+	}
+	return filepath.Join(mustExpand("~/.config"), "try", "config.toml")
+}
+
+// loadConfig layers the config file and TRY_PATH over the defaults. Flags are
+// applied by the caller, which completes flag > env > config > default.
+func loadConfig(path string) (config, error) {
+	c := defaultConfig()
+	var fc fileConfig
+	md, err := toml.DecodeFile(path, &fc)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err == nil {
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return c, fmt.Errorf("%s: unknown key %s", path, undecoded[0])
+		}
+		if err := c.merge(fc); err != nil {
+			return c, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if roots := splitRoots(os.Getenv("TRY_PATH")); len(roots) > 0 {
+		c.Roots = roots
+		c.sources["path"] = "env"
+	}
+	return c, nil
+}
+
+// splitRoots parses a TRY_PATH style list ("a:b" on Unix) into expanded,
+// de-duplicated directories.
+func splitRoots(list string) []string {
+	var roots []string
+	for _, p := range filepath.SplitList(list) {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if p = mustExpand(p); !slices.Contains(roots, p) {
+			roots = append(roots, p)
+		}
+	}
+	return roots
+}
+
+func (c *config) merge(fc fileConfig) error {
+	if fc.Path != nil {
+		var roots []string
+		switch v := fc.Path.(type) {
+		case string:
+			roots = splitRoots(v)
+		case []any:
+			for _, item := range v {
+				p, ok := item.(string)
+				if !ok {
+					return errors.New("path must be a string or a list of strings")
+				}
+				roots = append(roots, splitRoots(p)...)
+			}
+		default:
+			return errors.New("path must be a string or a list of strings")
+		}
+		if len(roots) == 0 {
+			return errors.New("path must not be empty")
+		}
+		c.Roots = roots
+		c.sources["path"] = "config"
+	}
+	if fc.DateFormat != nil {
+		if *fc.DateFormat == "" {
+			return errors.New("date_format must not be empty")
+		}
+		if err := checkDateFormat(*fc.DateFormat); err != nil {
+			return err
+		}
+		c.DateFormat = *fc.DateFormat
+		c.sources["date_format"] = "config"
+	}
+	if fc.Separator != nil {
+		if strings.ContainsAny(*fc.Separator, `/\`) {
+			return fmt.Errorf("separator must not contain path separators: %q", *fc.Separator)
+		}
+		c.Separator = *fc.Separator
+		c.sources["separator"] = "config"
+	}
+	if fc.DefaultHost != nil {
+		c.DefaultHost = strings.TrimSpace(*fc.DefaultHost)
+		c.sources["default_host"] = "config"
+	}
+	if fc.Mirror != nil {
+		c.Mirror = *fc.Mirror
+		c.sources["mirror"] = "config"
+	}
+	if fc.GitStatus != nil {
+		c.GitStatus = *fc.GitStatus
+		c.sources["git_status"] = "config"
+	}
+	for name, keys := range fc.Keys {
+		if !slices.Contains(keyNames, name) {
+			return fmt.Errorf("unknown key binding %q", name)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("key binding %q has no keys", name)
+		}
+		c.Keys[name] = keys
+		c.sources["keys."+name] = "config"
+	}
+	for name, color := range fc.Theme {
+		if _, ok := c.Theme[name]; !ok {
+			return fmt.Errorf("unknown theme colour %q", name)
+		}
+		c.Theme[name] = color
+		c.sources["theme."+name] = "config"
+	}
+	weights := map[string]*float64{
+		"date_prefix": &c.Score.DatePrefix,
+		"created":     &c.Score.Created,
+		"touched":     &c.Score.Touched,
+		"frecency":    &c.Score.Frecency,
+	}
+	for name, v := range fc.Score {
+		w, ok := weights[name]
+		if !ok {
+			return fmt.Errorf("unknown score weight %q", name)
+		}
+		*w = v
+		c.sources["score."+name] = "config"
+	}
+	for name, cmd := range fc.Hooks {
+		if !slices.Contains(hookNames, name) {
+			return fmt.Errorf("unknown hook %q", name)
+		}
+		c.Hooks[name] = cmd
+		c.sources["hooks."+name] = "config"
+	}
+	for name, v := range fc.GC {
+		switch name {
+		case "untouched_for":
+			if _, err := parseAge(v); err != nil {
+				return fmt.Errorf("gc.untouched_for: %w", err)
+			}
+			c.GC.UntouchedFor = v
+		case "action":
+			if v != "trash" && v != "archive" {
+				return fmt.Errorf("gc.action must be \"trash\" or \"archive\", got %q", v)
+			}
+			c.GC.Action = v
+		default:
+			return fmt.Errorf("unknown gc setting %q", name)
+		}
+		c.sources["gc."+name] = "config"
+	}
+	return nil
+}
+
+func (c config) source(name string) string {
+	if src, ok := c.sources[name]; ok {
+		return src
+	}
+	return "default"
+}
+
+// writeConfig prints the effective settings as TOML, each annotated with
+// where it came from.
+func (c config) writeConfig(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	line := func(name string, value any) {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", name, tomlValue(value), c.source(name))
+	}
+	fmt.Fprintf(tw, "# %s\n", configPath())
+	if len(c.Roots) == 1 {
+		line("path", c.Roots[0])
+	} else {
+		line("path", c.Roots)
+	}
+	line("date_format", c.DateFormat)
+	line("separator", c.Separator)
+	line("default_host", c.DefaultHost)
+	line("mirror", c.Mirror)
+	line("git_status", c.GitStatus)
+	fmt.Fprintln(tw, "\n[keys]")
+	for _, name := range keyNames {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", name, tomlValue(c.Keys[name]), c.source("keys."+name))
+	}
+	fmt.Fprintln(tw, "\n[theme]")
+	themeNames := make([]string, 0, len(c.Theme))
+	for name := range c.Theme {
+		themeNames = append(themeNames, name)
+	}
+	slices.Sort(themeNames)
+	for _, name := range themeNames {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", name, tomlValue(c.Theme[name]), c.source("theme."+name))
+	}
+	fmt.Fprintln(tw, "\n[score]")
+	for _, sw := range []struct {
+		name string
+		v    float64
+	}{
+		{"date_prefix", c.Score.DatePrefix},
+		{"created", c.Score.Created},
+		{"touched", c.Score.Touched},
+		{"frecency", c.Score.Frecency},
+	} {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", sw.name, tomlValue(sw.v), c.source("score."+sw.name))
+	}
+	fmt.Fprintln(tw, "\n[hooks]")
+	for _, name := range hookNames {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", name, tomlValue(c.Hooks[name]), c.source("hooks."+name))
+	}
+	fmt.Fprintln(tw, "\n[gc]")
+	fmt.Fprintf(tw, "untouched_for = %s\t# %s\n", tomlValue(c.GC.UntouchedFor), c.source("gc.untouched_for"))
+	fmt.Fprintf(tw, "action = %s\t# %s\n", tomlValue(c.GC.Action), c.source("gc.action"))
+	return tw.Flush()
+}
+
+func tomlValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func templatesDir() string {
+	return filepath.Join(filepath.Dir(configPath()), "templates")
+}
+
+func templatePath(name string) string {
+	return filepath.Join(templatesDir(), name)
+}
+
+func listTemplates() ([]string, error) {
+	dirs, err := os.ReadDir(templatesDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, d := range dirs {
+		if d.IsDir() && !strings.HasPrefix(d.Name(), ".") {
+			names = append(names, d.Name())
+		}
+	}
+	return names, nil
+}
+
+// dateFormatSamples are dates whose month names, weekday names, days and
+// hours differ in length, to tell fixed-width layouts from the rest.
+var dateFormatSamples = []time.Time{
+	time.Date(2006, time.January, 2, 3, 4, 5, 0, time.UTC),
+	time.Date(2025, time.May, 7, 12, 30, 0, 0, time.UTC),
+	time.Date(2024, time.September, 30, 23, 59, 59, 0, time.UTC),
+	time.Date(2026, time.December, 19, 9, 0, 0, 0, time.UTC),
+}
+
+// checkDateFormat rejects date layouts that would put a path separator in a
+// try name or whose formatted width varies; the date prefix of a name is
+// found by its width.
+func checkDateFormat(layout string) error {
+	width := -1
+	for _, t := range dateFormatSamples {
+		date := t.Format(layout)
+		if strings.ContainsAny(date, `/\`) {
+			return fmt.Errorf("date_format must not produce path separators: %q", layout)
+		}
+		if width >= 0 && len(date) != width {
+			return fmt.Errorf("date_format must format every date to the same width: %q", layout)
+		}
+		width = len(date)
+	}
+	return nil
+}
+
+// templateVars describes a new try for {{name}}, {{date}}, {{dir}} and
+// {{author}} placeholders in template files and file names.
+func templateVars(dir string) map[string]string {
+	base := filepath.Base(dir)
+	_, name := splitDatePrefix(base)
+	author := os.Getenv("USER")
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil && strings.TrimSpace(string(out)) != "" {
+		author = strings.TrimSpace(string(out))
+	}
+	return map[string]string{
+		"name":   name,
+		"date":   time.Now().Format(cfg.DateFormat),
+		"dir":    base,
+		"author": author,
+	}
+}
+
+// applyTemplate copies the template tree src into dst, substituting
+// {{var}} placeholders in file names and in the contents of text files.
+func applyTemplate(src, dst string, vars map[string]string) error {
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, r.Replace(rel))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(data[:min(len(data), 8000)], 0) {
+				data = []byte(r.Replace(string(data)))
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+	})
+}
+
+// datedName prefixes name with today's date in the configured format.
+func datedName(name string) string {
+	return time.Now().Format(cfg.DateFormat) + cfg.Separator + name
+}
+
+// splitDatePrefix splits name into its date prefix (including the separator)
+// and the rest; prefix is empty when name has none.
+func splitDatePrefix(name string) (prefix, rest string) {
+	if !hasDatePrefix(name) {
+		return "", name
 	}
+	n := len(time.Now().Format(cfg.DateFormat)) + len(cfg.Separator)
+	return name[:n], name[n:]
 }
 
-func defaultTryPath() string {
-	if v := strings.TrimSpace(os.Getenv("TRY_PATH")); v != "" {
-		return mustExpand(v)
+// hasDatePrefix reports whether name starts with a date in the configured
+// format followed by the separator.
+func hasDatePrefix(name string) bool {
+	n := len(time.Now().Format(cfg.DateFormat))
+	if len(name) <= n {
+		return false
 	}
-	return mustExpand("~/src/tries")
+	if _, err := time.Parse(cfg.DateFormat, name[:n]); err != nil {
+		return false
+	}
+	return strings.HasPrefix(name[n:], cfg.Separator)
 }
 
 func mustExpand(path string) string {
@@ -129,7 +915,7 @@ func mustExpand(path string) string {
 }
 
 func shellQuote(s string) string {
-	return "'" + strings.ReplaceAll(s, "'", `"'"'"`) + "'"
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
 }
 
 func emitScript(w io.Writer, cmds []string) {
@@ -150,23 +936,131 @@ func emitScript(w io.Writer, cmds []string) {
 
 func scriptCD(path string) []string {
 	q := shellQuote(path)
-	return []string{"touch " + q, "echo " + q, "cd " + q}
+	return []string{"echo " + q, "cd " + q}
 }
 
 func scriptMkdirCD(path string) []string {
 	return append([]string{"mkdir -p " + shellQuote(path)}, scriptCD(path)...)
 }
 
-func scriptClone(path, uri string) []string {
-	msg := fmt.Sprintf("Using git clone to create this trial from %s.", uri)
+func scriptClone(path, uri string, gitArgs []string) []string {
+	msg := fmt.Sprintf("Using git clone to create this trial from %s.", redactURI(uri))
+	clone := "git clone"
+	for _, arg := range gitArgs {
+		clone += " " + shellQuote(arg)
+	}
+	cmds := []string{
+		"mkdir -p " + shellQuote(path),
+		"echo " + shellQuote(msg),
+		clone + " " + shellQuote(uri) + " " + shellQuote(path),
+	}
+	return append(cmds, scriptCD(path)...)
+}
+
+// scriptMirror creates or refreshes the bare mirror of uri. It tracks
+// branches (and the tags they carry) only, so forge refs such as
+// refs/pull/* are not downloaded. Failures are not fatal: the clone then
+// simply falls back to fetching everything.
+func scriptMirror(mirror, uri string) []string {
+	update := `if [ -d "$1" ]; then git --git-dir="$1" fetch --quiet --prune; else git clone --quiet --bare "$2" "$1" && git --git-dir="$1" config remote.origin.fetch '+refs/heads/*:refs/heads/*'; fi && touch "$1" || echo "try: could not update mirror $1" >&2`
+	return []string{"sh -c " + shellQuote(update) + " try-mirror " + shellQuote(mirror) + " " + shellQuote(uri)}
+}
+
+// scriptCheckoutRef fetches a pull/merge request ref into a local branch and
+// checks it out.
+func scriptCheckoutRef(path, ref, branch string) []string {
+	q := shellQuote(path)
+	return []string{
+		"git -C " + q + " fetch origin " + shellQuote(ref+":"+branch),
+		"git -C " + q + " checkout " + shellQuote(branch),
+	}
+}
+
+func scriptWorktree(path, repoDir string) []string {
+	msg := fmt.Sprintf("Using git worktree to create this trial from %s.", repoDir)
 	cmds := []string{
 		"mkdir -p " + shellQuote(path),
 		"echo " + shellQuote(msg),
-		"git clone " + shellQuote(uri) + " " + shellQuote(path),
+		"git -C " + shellQuote(repoDir) + " worktree add --detach " + shellQuote(path),
 	}
 	return append(cmds, scriptCD(path)...)
 }
 
+func scriptTrash(path, basePath string, now time.Time) []string {
+	base := filepath.Base(path)
+	qBasePath := shellQuote(basePath)
+	dest := filepath.Join(trashDirName, trashName(base, now))
+	return []string{
+		"old_pwd=$PWD",
+		"cd " + qBasePath,
+		"mkdir -p " + shellQuote(trashDirName),
+		"test -d " + shellQuote(base) + " && mv " + shellQuote(base) + " " + shellQuote(dest),
+		"echo " + shellQuote("Moved "+base+" to "+filepath.Join(basePath, dest)),
+		"{ cd \"$old_pwd\" 2>/dev/null || cd " + qBasePath + "; }",
+	}
+}
+
+// scriptArchive packs a try into archivePath (relative to basePath) and
+// removes the directory, leaving the shell where it was unless it was inside.
+func scriptArchive(path, basePath, archivePath string) []string {
+	base := filepath.Base(path)
+	qBasePath := shellQuote(basePath)
+	return []string{
+		"old_pwd=$PWD",
+		"cd " + qBasePath,
+		"mkdir -p " + shellQuote(archiveDirName),
+		"test -d " + shellQuote(base) + " && tar -czf " + shellQuote(archivePath) + " " + shellQuote(base) + " && rm -rf " + shellQuote(base),
+		"echo " + shellQuote("Archived "+base+" to "+filepath.Join(basePath, archivePath)),
+		"{ cd \"$old_pwd\" 2>/dev/null || cd " + qBasePath + "; }",
+	}
+}
+
+// scriptUnarchive unpacks an archive into target, dropping the archive's
+// top-level directory so target may differ from the archived name.
+func scriptUnarchive(archive, target string) []string {
+	qTarget := shellQuote(target)
+	return append([]string{
+		"mkdir -p " + qTarget,
+		"tar -xf " + shellQuote(archive) + " -C " + qTarget + " --strip-components=1",
+		"rm -f " + shellQuote(archive),
+	}, scriptCD(target)...)
+}
+
+func scriptRestore(trashed, target string) []string {
+	return append([]string{"mv " + shellQuote(trashed) + " " + shellQuote(target)}, scriptCD(target)...)
+}
+
+func scriptPurge(paths []string) []string {
+	if len(paths) == 0 {
+		return []string{"echo " + shellQuote("Nothing to purge.")}
+	}
+	cmds := make([]string, 0, len(paths)+1)
+	cmds = append(cmds, "echo "+shellQuote(fmt.Sprintf("Purging %d trashed tries.", len(paths))))
+	for _, p := range paths {
+		cmds = append(cmds, "rm -rf "+shellQuote(p))
+	}
+	return cmds
+}
+
+// scriptHook runs the configured command for event inside the try, with
+// TRY_NAME, TRY_PATH and TRY_SOURCE_URI set. A failing hook is reported but
+// never breaks the && chain it is spliced into; the fallback is grouped so
+// it cannot swallow a failure from earlier in the chain either.
+func scriptHook(event, path, sourceURI string) []string {
+	cmd := strings.TrimSpace(cfg.Hooks[event])
+	if cmd == "" {
+		return nil
+	}
+	// One sh -c call keeps the spliced command valid in whatever shell
+	// evals the script, and its exit status is always 0.
+	run := `cd "$1" && TRY_NAME="$2" TRY_PATH="$1" TRY_SOURCE_URI="$3" sh -c "$4" || echo "try: $5 hook failed" >&2`
+	args := []string{path, filepath.Base(path), sourceURI, cmd, event}
+	for i, a := range args {
+		args[i] = shellQuote(a)
+	}
+	return []string{"sh -c " + shellQuote(run) + " try-hook " + strings.Join(args, " ")}
+}
+
 func scriptDelete(path, basePath string) []string {
 	base := filepath.Base(path)
 	qBasePath := shellQuote(basePath)
@@ -174,48 +1068,256 @@ func scriptDelete(path, basePath string) []string {
 		"old_pwd=$PWD",
 		"cd " + qBasePath,
 		"test -d " + shellQuote(base) + " && rm -rf " + shellQuote(base),
-		"cd \"$old_pwd\" 2>/dev/null || cd " + qBasePath,
+		"{ cd \"$old_pwd\" 2>/dev/null || cd " + qBasePath + "; }",
 	}
 }
 
+// parseGitURI understands URLs (https, http, ssh, git, git+ssh, file),
+// scp-like "user@host:path" remotes, and the "gh:user/repo" and "user/repo"
+// shorthands, the latter resolved against cfg.DefaultHost.
 func parseGitURI(uri string) (*gitURI, bool) {
-	trimmed := strings.TrimSuffix(strings.TrimSpace(uri), ".git")
-	if trimmed == "" {
+	uri = strings.TrimSpace(uri)
+	if uri == "" || strings.ContainsAny(uri, " \t") {
 		return nil, false
 	}
-	if m := httpsGitURIRe.FindStringSubmatch(trimmed); len(m) == 4 {
-		return &gitURI{Host: m[1], User: m[2], Repo: m[3]}, true
+	if strings.Contains(uri, "://") {
+		return parseGitURL(uri)
+	}
+	if alias, rest, ok := strings.Cut(uri, ":"); ok && !strings.Contains(alias, "/") {
+		if host, ok := gitShorthandHosts[alias]; ok {
+			return gitShorthand(host, rest)
+		}
+		// user@host:path, host.tld:path, or a bare ssh config alias.
+		if strings.Contains(alias, "@") || strings.Contains(alias, ".") || sshAliasRe.MatchString(alias) {
+			host := alias[strings.LastIndex(alias, "@")+1:]
+			g, ok := splitGitPath(rest, false)
+			if !ok || host == "" {
+				return nil, false
+			}
+			g.Host = host
+			g.URL = uri
+			return g, true
+		}
+		return nil, false
 	}
-	if m := sshGitURIRe.FindStringSubmatch(trimmed); len(m) == 4 {
-		return &gitURI{Host: m[1], User: m[2], Repo: m[3]}, true
+	if strings.Count(uri, "/") == 1 && !strings.HasPrefix(uri, ".") {
+		return gitShorthand(cfg.DefaultHost, uri)
 	}
 	return nil, false
 }
 
-func isGitURI(arg string) bool {
-	arg = strings.TrimSpace(arg)
-	if arg == "" {
-		return false
+func parseGitURL(uri string) (*gitURI, bool) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, false
 	}
-	if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "git@") {
-		return true
+	switch u.Scheme {
+	case "file":
+		g, ok := splitGitPath(u.Path, false)
+		if !ok {
+			return nil, false
+		}
+		g.URL = uri
+		return g, true
+	case "http", "https":
+		g, ok := splitGitPath(u.Path, true)
+		if !ok || u.Host == "" {
+			return nil, false
+		}
+		g.Host, g.Port = u.Hostname(), u.Port()
+		userinfo := ""
+		if u.User != nil {
+			userinfo = u.User.String() + "@"
+		}
+		g.URL = u.Scheme + "://" + userinfo + u.Host + "/" + strings.TrimPrefix(g.URL, "/")
+		return g, true
+	case "ssh", "git", "git+ssh":
+		g, ok := splitGitPath(u.Path, false)
+		if !ok || u.Host == "" {
+			return nil, false
+		}
+		g.Host, g.Port = u.Hostname(), u.Port()
+		g.URL = uri
+		return g, true
 	}
-	return strings.Contains(arg, "github.com") || strings.Contains(arg, "gitlab.com") || strings.HasSuffix(arg, ".git")
+	return nil, false
 }
 
-func generateCloneDirectoryName(uri, customName string) (string, error) {
-	if strings.TrimSpace(customName) != "" {
-		return sanitizeName(customName), nil
+// redactURI hides the password or token in a URL so it is not echoed or
+// stored.
+func redactURI(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
 	}
-	parsed, ok := parseGitURI(uri)
+	return uri
+}
+
+// splitGitPath splits "group/sub/repo.git" into owner and repo. With web set,
+// forge UI suffixes such as /tree/main are cut off first. The returned URL
+// field holds the path that was kept.
+func splitGitPath(path string, web bool) (*gitURI, bool) {
+	segs := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	var ref, refName string
+	if web {
+		for i := 2; i < len(segs); i++ {
+			if slices.Contains(gitWebMarkers, segs[i]) {
+				ref, refName = changeRef(segs[i:])
+				segs = segs[:i]
+				break
+			}
+		}
+	}
+	if len(segs) < 2 {
+		return nil, false
+	}
+	repo := strings.TrimSuffix(segs[len(segs)-1], ".git")
+	if repo == "" {
+		return nil, false
+	}
+	return &gitURI{
+		User:    strings.Join(segs[:len(segs)-1], "/"),
+		Repo:    repo,
+		URL:     strings.Join(segs, "/"),
+		Ref:     ref,
+		RefName: refName,
+	}, true
+}
+
+// changeRef maps the web UI tail of a pull or merge request link
+// ("pull/123", "-/merge_requests/45") to the ref that holds its head.
+func changeRef(tail []string) (string, string) {
+	if len(tail) > 0 && tail[0] == "-" {
+		tail = tail[1:]
+	}
+	if len(tail) < 2 {
+		return "", ""
+	}
+	if _, err := strconv.Atoi(tail[1]); err != nil {
+		return "", ""
+	}
+	switch tail[0] {
+	case "pull":
+		return "refs/pull/" + tail[1] + "/head", "pr" + tail[1]
+	case "merge_requests":
+		return "refs/merge-requests/" + tail[1] + "/head", "mr" + tail[1]
+	}
+	return "", ""
+}
+
+func gitShorthand(host, path string) (*gitURI, bool) {
+	if host == "" || !gitPathRe.MatchString(path) {
+		return nil, false
+	}
+	g, ok := splitGitPath(path, false)
+	if !ok {
+		return nil, false
+	}
+	g.Host = host
+	g.URL = "https://" + host + "/" + g.User + "/" + g.Repo + ".git"
+	return g, true
+}
+
+func isGitURI(arg string) bool {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return false
+	}
+	for _, prefix := range []string{"http://", "https://", "git@", "ssh://", "git://", "git+ssh://", "file://"} {
+		if strings.HasPrefix(arg, prefix) {
+			return true
+		}
+	}
+	if alias, _, ok := strings.Cut(arg, ":"); ok {
+		if _, ok := gitShorthandHosts[alias]; ok {
+			return true
+		}
+	}
+	return strings.Contains(arg, "github.com") || strings.Contains(arg, "gitlab.com") || strings.HasSuffix(arg, ".git")
+}
+
+func generateCloneDirectoryName(uri, customName string) (string, error) {
+	if strings.TrimSpace(customName) != "" {
+		return sanitizeName(customName), nil
+	}
+	parsed, ok := parseGitURI(uri)
 	if !ok {
 		return "", fmt.Errorf("unable to parse git URI: %s", uri)
 	}
-	return fmt.Sprintf("%s-%s-%s", time.Now().Format("2006-01-02"), parsed.User, parsed.Repo), nil
+	owner := strings.ReplaceAll(parsed.User, "/", cfg.Separator)
+	name := owner + cfg.Separator + parsed.Repo
+	if parsed.RefName != "" {
+		name += cfg.Separator + parsed.RefName
+	}
+	return datedName(name), nil
+}
+
+func generateWorktreeDirectoryName(repoDir, customName string) string {
+	name := sanitizeName(customName)
+	if name == "" {
+		name = sanitizeName(filepath.Base(repoDir))
+	}
+	return datedName(name)
+}
+
+func gitToplevel(dir string) (string, bool) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", false
+	}
+	top := strings.TrimSpace(string(out))
+	return top, top != ""
+}
+
+func isWorktreeSource(arg string) bool {
+	if arg != "." && !strings.HasPrefix(arg, "./") && !strings.HasPrefix(arg, "../") {
+		return false
+	}
+	st, err := os.Stat(arg)
+	return err == nil && st.IsDir()
+}
+
+// trashName prefixes a try name with the time it was trashed so repeated
+// deletions of the same name never collide.
+func trashName(name string, at time.Time) string {
+	return at.Format(trashStampLayout) + "-" + name
+}
+
+// untrashName splits a trash entry name into its original name and the time
+// it was trashed. ok is false when the name carries no trash stamp.
+func untrashName(name string) (string, time.Time, bool) {
+	if len(name) <= len(trashStampLayout)+1 || name[len(trashStampLayout)] != '-' {
+		return name, time.Time{}, false
+	}
+	at, err := time.ParseInLocation(trashStampLayout, name[:len(trashStampLayout)], time.Local)
+	if err != nil {
+		return name, time.Time{}, false
+	}
+	return name[len(trashStampLayout)+1:], at, true
+}
+
+// parseAge parses durations like "30d" and "2w" on top of time.ParseDuration.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			var v float64
+			if _, err := fmt.Sscanf(n, "%g", &v); err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age: %s", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age: %s", s)
+	}
+	return d, nil
 }
 
 func sanitizeName(name string) string {
-	return strings.Join(strings.Fields(strings.TrimSpace(name)), "-")
+	return strings.Join(strings.Fields(strings.TrimSpace(name)), cfg.Separator)
 }
 
 func printHelp(w io.Writer) {
@@ -223,18 +1325,72 @@ func printHelp(w io.Writer) {
 
 Usage:
   try [query]           Interactive directory selector
-  try clone <url>       Clone repo into dated directory
+  try clone [git options] <url> [name]
+                        Clone repo into dated directory; passes through
+                        --branch, --depth, --filter, --recurse-submodules,
+                        --shallow-submodules, --single-branch, --sparse.
+                        Pull/merge request links check out the request head
+  try new [--template T] <name>
+                        Create a dated try, optionally from a template
+  try . [name]          Create dated worktree for current repo
+  try worktree dir [name]
+                        Same as above, explicit form (dir may be a repo path)
+  try restore [query]   Restore a try from the trash
+  try archive <name>    Pack a try into .archive/<name>.tar.gz and remove it
+  try unarchive [query] Pick an archived try and unpack it
+  try pin|unpin <name>  Keep a try at the top of the selector, or stop
+  try tag add|rm <name> <tag>...
+                        Tag a try; search with #tag or tag:name in the query
+  try tag ls [name]     List tags and how many tries carry them
+  try describe <name> [text]
+                        Set (or clear) a one-line description
+  try gc [--untouched-for 90d] [--dry-run] [--archive]
+                        Trash (or archive) tries unused for a while, skipping
+                        pinned tries and git checkouts with uncommitted changes
+  try trash empty [--older-than 30d]
+                        Permanently remove trashed tries
+  try list [query]      Print ranked tries without the TUI
+                        (--format table|json|paths, -0, --limit N,
+                         --sort score|name|created|touched)
+  try du [--top 20] [--json]
+                        Show the largest tries; sizes are cached per directory
+  try config show       Print effective settings and where they came from
+  try cache list|prune [--older-than 30d]
+                        Show or remove the bare mirrors used to speed up clones
   try init [path]       Output shell function definition
   try --help            Show this help
 
+Options:
+  --path <dir>          Tries directory
+  --hard                Ctrl-D deletes permanently instead of moving to trash
+  --no-git              Skip the git status column in the selector
+
 Environment:
-  TRY_PATH          Tries directory (default: ~/src/tries)
+  TRY_PATH          Tries directories, colon separated (default: ~/src/tries)
+  XDG_CONFIG_HOME   Config is read from $XDG_CONFIG_HOME/try/config.toml
+  XDG_STATE_HOME    Visit history lives in $XDG_STATE_HOME/try (default: ~/.local/state/try)
 
 Keyboard:
   ↑/↓, Ctrl-P/N     Navigate
+  PgUp/PgDn          Scroll by page
+  Home/End           Jump to first / last row
   Enter              Select / Create new
-  Ctrl-D             Delete selected try (confirm with YES)
+  Tab                Mark / unmark try for a batch action
+  Ctrl-A             At the start of the query: mark all shown tries
+                     (again to unmark)
+  Ctrl-D             Move selected try to trash after a summary of what it
+                     holds (confirm with YES, or the name if work would be lost)
+  Ctrl-R             Rename selected try, keeping its date prefix
+  Ctrl-X             Archive selected or marked tries after confirming
+  Ctrl-G             Add tags, or remove them with -tag
+  Ctrl-S             Pin / unpin selected try
+  Ctrl-O             Toggle the preview pane
+  Ctrl-L             Show sizes / sort by size / hide sizes
+  Ctrl-T             Cycle the root new tries are created in
+  ←/→, Ctrl-A/E      Move in query / jump to start or end
+  Alt-B/F            Move by word
   Backspace          Delete character
+  Ctrl-W / Ctrl-U    Delete word / clear query
   Esc                Cancel
 `, version)
 }
@@ -244,6 +1400,8 @@ func fishShell() bool {
 	return strings.Contains(shell, "fish")
 }
 
+// initScript renders the shell wrapper. It looks past leading options such
+// as --path X to find the subcommand, so direct commands are never eval'd.
 func initScript(exePath, triesPath string) string {
 	pathArg := ""
 	if triesPath != "" {
@@ -251,6 +1409,26 @@ func initScript(exePath, triesPath string) string {
 	}
 	if fishShell() {
 		return fmt.Sprintf(`function try
+  set -l cmd
+  set -l skip 0
+  for arg in $argv
+    if test $skip -eq 1
+      set skip 0
+      continue
+    end
+    switch $arg
+      case --path
+        set skip 1
+      case '-*'
+      case '*'
+        set cmd $arg
+        break
+    end
+  end
+  if contains -- "$cmd" %s
+    %s%s $argv
+    return $status
+  end
   set -l out (%s exec%s $argv 2>/dev/tty | string collect)
   if test $pipestatus[1] -eq 0
     eval $out
@@ -258,9 +1436,27 @@ func initScript(exePath, triesPath string) string {
     echo $out
   end
 end
-`, shellQuote(exePath), pathArg)
+`, strings.Join(directCommands, " "), shellQuote(exePath), pathArg, shellQuote(exePath), pathArg)
 	}
 	return fmt.Sprintf(`try() {
+  local arg cmd= skip=
+  for arg in "$@"; do
+    if [ -n "$skip" ]; then
+      skip=
+      continue
+    fi
+    case "$arg" in
+      --path) skip=1 ;;
+      -*) ;;
+      *) cmd=$arg; break ;;
+    esac
+  done
+  case "$cmd" in
+    %s)
+      %s%s "$@"
+      return $?
+      ;;
+  esac
   local out
   out=$(%s exec%s "$@" 2>/dev/tty)
   if [ $? -eq 0 ]; then
@@ -269,7 +1465,7 @@ end
     echo "$out"
   fi
 }
-`, shellQuote(exePath), pathArg)
+`, strings.Join(directCommands, "|"), shellQuote(exePath), pathArg, shellQuote(exePath), pathArg)
 }
 
 func extractOption(args []string, opt string) ([]string, string) {
@@ -295,7 +1491,52 @@ func extractOption(args []string, opt string) ([]string, string) {
 	return args, value
 }
 
+func extractFlag(args []string, flag string) ([]string, bool) {
+	idx := slices.Index(args, flag)
+	if idx == -1 {
+		return args, false
+	}
+	return slices.Delete(args, idx, idx+1), true
+}
+
+// splitCloneArgs separates passthrough git clone options from the URI and
+// the optional custom name.
+func splitCloneArgs(args []string) (gitArgs, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case slices.Contains(cloneBoolFlags, arg):
+			gitArgs = append(gitArgs, arg)
+			continue
+		case !slices.Contains(cloneValueFlags, name):
+			return nil, nil, fmt.Errorf("unknown clone option: %s", arg)
+		case !hasValue:
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "--depth" {
+			if n, err := strconv.Atoi(value); err != nil || n < 1 {
+				return nil, nil, fmt.Errorf("invalid --depth: %s", value)
+			}
+		}
+		gitArgs = append(gitArgs, name, value)
+	}
+	return gitArgs, rest, nil
+}
+
 func cmdClone(args []string, triesPath string) ([]string, error) {
+	gitArgs, args, err := splitCloneArgs(args)
+	if err != nil {
+		return nil, err
+	}
 	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
 		return nil, errors.New("git URI required for clone command")
 	}
@@ -308,7 +1549,291 @@ func cmdClone(args []string, triesPath string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	return scriptClone(filepath.Join(triesPath, dirName), uri), nil
+	parsed, ok := parseGitURI(uri)
+	if ok {
+		uri = parsed.URL
+	}
+	target := filepath.Join(triesPath, dirName)
+	// A shallow or partial clone asked for less than the mirror would fetch.
+	var mirrorCmds []string
+	if ok && cfg.Mirror && !limitsHistory(gitArgs) {
+		path := mirrorPath(parsed)
+		mirrorCmds = scriptMirror(path, uri)
+		gitArgs = append([]string{"--reference-if-able", path, "--dissociate"}, gitArgs...)
+	}
+	cmds := scriptClone(target, uri, gitArgs)
+	// Refresh the mirror right before git clone, after the mkdir and message.
+	cmds = append(cmds[:2:2], append(mirrorCmds, cmds[2:]...)...)
+	if ok && parsed.Ref != "" {
+		// Check out before the trailing echo/cd so the shell lands on the branch.
+		cd := scriptCD(target)
+		cmds = append(cmds[:len(cmds)-len(cd)], scriptCheckoutRef(target, parsed.Ref, parsed.RefName)...)
+		cmds = append(cmds, cd...)
+	}
+	return append(cmds, scriptHook("on_clone", target, uri)...), nil
+}
+
+// limitsHistory reports whether clone options make a shallow or partial
+// clone.
+func limitsHistory(gitArgs []string) bool {
+	return slices.ContainsFunc(gitArgs, func(arg string) bool {
+		return strings.HasPrefix(arg, "--depth") || strings.HasPrefix(arg, "--shallow-") || strings.HasPrefix(arg, "--filter")
+	})
+}
+
+func cmdWorktree(args []string, triesPath string) ([]string, error) {
+	source := "."
+	if len(args) > 0 {
+		if args[0] != "dir" {
+			source = args[0]
+		}
+		args = args[1:]
+	}
+	repoDir := mustExpand(source)
+	st, err := os.Stat(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", repoDir)
+	}
+	// Inside a repo the try is named after the repo, not the subdirectory
+	// try was run from.
+	top, inRepo := gitToplevel(repoDir)
+	nameDir := repoDir
+	if inRepo {
+		nameDir = top
+	}
+	target := uniquePath(filepath.Join(triesPath, generateWorktreeDirectoryName(nameDir, strings.Join(args, " "))))
+	if inRepo {
+		return append(scriptWorktree(target, top), scriptHook("on_create", target, top)...), nil
+	}
+	return append(scriptMkdirCD(target), scriptHook("on_create", target, "")...), nil
+}
+
+func stateDir() string {
+	if v := strings.TrimSpace(os.Getenv("XDG_STATE_HOME")); v != "" {
+		return filepath.Join(mustExpand(v), "try")
+	}
+	return filepath.Join(mustExpand("~/.local/state"), "try")
+}
+
+func cacheDir() string {
+	if v := strings.TrimSpace(os.Getenv("XDG_CACHE_HOME")); v != "" {
+		return filepath.Join(mustExpand(v), "try")
+	}
+	return filepath.Join(mustExpand("~/.cache"), "try")
+}
+
+func mirrorsDir() string {
+	return filepath.Join(cacheDir(), "mirrors")
+}
+
+// mirrorPath is where the bare mirror for a parsed URI lives:
+// mirrors/<host>/<user>/<repo>.git.
+func mirrorPath(u *gitURI) string {
+	host := u.Host
+	if host == "" {
+		host = "local"
+	}
+	if u.Port != "" {
+		host += "_" + u.Port
+	}
+	return filepath.Join(mirrorsDir(), host, filepath.FromSlash(u.User), u.Repo+".git")
+}
+
+func historyPath() string {
+	return filepath.Join(stateDir(), "history.json")
+}
+
+func loadHistory(path string) (*history, error) {
+	h := &history{Visits: map[string][]time.Time{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return &history{Visits: map[string][]time.Time{}}, err
+	}
+	if h.Visits == nil {
+		h.Visits = map[string][]time.Time{}
+	}
+	return h, nil
+}
+
+func (h *history) save(path string) error {
+	return writeJSON(path, h)
+}
+
+// writeJSON replaces path with v encoded as JSON, via a temporary file so a
+// crash never leaves a truncated file behind.
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	// A temporary file of its own keeps concurrent writers of the same
+	// path from interleaving; the last rename wins.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func metadataPath() string {
+	return filepath.Join(stateDir(), "metadata.json")
+}
+
+func loadMetadata(path string) (*metadata, error) {
+	md := &metadata{Tries: map[string]*tryMeta{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return md, nil
+	}
+	if err != nil {
+		return md, err
+	}
+	if err := json.Unmarshal(data, md); err != nil {
+		return &metadata{Tries: map[string]*tryMeta{}}, err
+	}
+	if md.Tries == nil {
+		md.Tries = map[string]*tryMeta{}
+	}
+	return md, nil
+}
+
+// save drops entries with nothing set before writing.
+func (md *metadata) save(path string) error {
+	for p, t := range md.Tries {
+		if t == nil || t.empty() {
+			delete(md.Tries, p)
+		}
+	}
+	return writeJSON(path, md)
+}
+
+// get returns the metadata for path, creating it if needed.
+func (md *metadata) get(path string) *tryMeta {
+	t := md.Tries[path]
+	if t == nil {
+		t = &tryMeta{}
+		md.Tries[path] = t
+	}
+	return t
+}
+
+func (md *metadata) rename(from, to string) {
+	if t, ok := md.Tries[from]; ok {
+		md.Tries[to] = t
+		delete(md.Tries, from)
+	}
+}
+
+// updateMetadata loads the metadata store, applies fn and saves it.
+func updateMetadata(fn func(*metadata)) error {
+	path := metadataPath()
+	md, err := loadMetadata(path)
+	if err != nil {
+		return err
+	}
+	fn(md)
+	return md.save(path)
+}
+
+func (h *history) record(path string, at time.Time) {
+	visits := append(h.Visits[path], at)
+	if len(visits) > historyMaxVisits {
+		visits = visits[len(visits)-historyMaxVisits:]
+	}
+	h.Visits[path] = visits
+}
+
+// frecency combines how often and how recently a path was visited. Each
+// visit decays with historyHalfLife; the sum is log-scaled so a handful of
+// recent visits matter more than a long tail of old ones.
+func (h *history) frecency(path string, now time.Time) float64 {
+	sum := 0.0
+	for _, v := range h.Visits[path] {
+		age := now.Sub(v)
+		if age < 0 {
+			age = 0
+		}
+		sum += math.Pow(0.5, float64(age)/float64(historyHalfLife))
+	}
+	return 3 * math.Log1p(sum)
+}
+
+// rename moves the visits recorded for from over to to.
+func (h *history) rename(from, to string) {
+	if visits, ok := h.Visits[from]; ok {
+		h.Visits[to] = append(h.Visits[to], visits...)
+		delete(h.Visits, from)
+	}
+}
+
+// renameTry renames a try directory and carries its history and metadata
+// over to the new path.
+func renameTry(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	// A linked worktree's repository still points at the old path; repair
+	// it before git prunes the worktree as gone.
+	if st, err := os.Lstat(filepath.Join(to, ".git")); err == nil && st.Mode().IsRegular() {
+		if out, err := exec.Command("git", "-C", to, "worktree", "repair").CombinedOutput(); err != nil {
+			return fmt.Errorf("git worktree repair: %v: %s", err, bytes.TrimSpace(out))
+		}
+	}
+	hp := historyPath()
+	h, err := loadHistory(hp)
+	if err != nil {
+		return err
+	}
+	h.rename(from, to)
+	if err := h.save(hp); err != nil {
+		return err
+	}
+	return updateMetadata(func(md *metadata) { md.rename(from, to) })
+}
+
+// last returns the most recent visit to path, or the zero time.
+func (h *history) last(path string) time.Time {
+	var last time.Time
+	for _, v := range h.Visits[path] {
+		if v.After(last) {
+			last = v
+		}
+	}
+	return last
+}
+
+func recordVisit(path string) error {
+	hp := historyPath()
+	h, err := loadHistory(hp)
+	if err != nil {
+		return err
+	}
+	h.record(path, time.Now())
+	return h.save(hp)
 }
 
 func listEntries(basePath string) ([]entry, error) {
@@ -321,46 +1846,107 @@ func listEntries(basePath string) ([]entry, error) {
 	}
 	items := make([]entry, 0, len(dirs))
 	for _, d := range dirs {
-		if !d.IsDir() {
+		name := d.Name()
+		if !d.IsDir() || strings.HasPrefix(name, ".") {
 			continue
 		}
-		name := d.Name()
 		full := filepath.Join(basePath, name)
 		st, err := os.Stat(full)
 		if err != nil {
 			continue
 		}
-		created := st.ModTime()
-		if runtime.GOOS != "windows" {
-			created = fileCTime(st)
+		items = append(items, entry{Name: name, Path: full, Root: basePath, Touched: st.ModTime(), Created: fileCTime(full, st)})
+	}
+	return items, nil
+}
+
+// listArchives lists the archived tries of every root. Name is the try name
+// without the archive extension; Path is the archive file.
+func listArchives(roots []string) ([]entry, error) {
+	var items []entry
+	for _, root := range roots {
+		dir := filepath.Join(root, archiveDirName)
+		files, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			name, ok := archiveBaseName(f.Name())
+			if !ok || f.IsDir() {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+			items = append(items, entry{Name: name, Path: filepath.Join(dir, f.Name()), Root: root, Created: info.ModTime(), Touched: info.ModTime()})
 		}
-		items = append(items, entry{Name: name, Path: full, Touched: st.ModTime(), Created: created})
 	}
 	return items, nil
 }
 
-func fileCTime(fi os.FileInfo) time.Time {
-	return fi.ModTime()
+// archiveBaseName strips a known archive extension from file.
+func archiveBaseName(file string) (string, bool) {
+	for _, ext := range archiveExts {
+		if name, ok := strings.CutSuffix(file, ext); ok && name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// archivePathFor picks a free archive file name for a try in root, relative
+// to root, adding uniquePath's numeric suffix when one is taken.
+func archivePathFor(root, name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		taken := false
+		for _, ext := range archiveExts {
+			if _, err := os.Stat(filepath.Join(root, archiveDirName, candidate+ext)); err == nil {
+				taken = true
+			}
+		}
+		if !taken {
+			return filepath.Join(archiveDirName, candidate+archiveExt)
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// listAllEntries merges the entries of every root.
+func listAllEntries(roots []string) ([]entry, error) {
+	var all []entry
+	for _, root := range roots {
+		items, err := listEntries(root)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+	return all, nil
 }
 
 func baseScore(e entry) float64 {
 	now := time.Now()
+	w := cfg.Score
 	score := 0.0
-	if len(e.Name) >= len("2006-01-02-") {
-		if _, err := time.Parse("2006-01-02", e.Name[:10]); err == nil && e.Name[10] == '-' {
-			score += 2.0
-		}
+	if hasDatePrefix(e.Name) {
+		score += w.DatePrefix
 	}
 	days := now.Sub(e.Created).Hours() / 24
 	if days < 0 {
 		days = 0
 	}
-	score += 2 / sqrt(days+1)
+	score += w.Created / sqrt(days+1)
 	hours := now.Sub(e.Touched).Hours()
 	if hours < 0 {
 		hours = 0
 	}
-	score += 3 / sqrt(hours+1)
+	score += w.Touched / sqrt(hours+1)
+	score += w.Frecency * e.Frecency
 	return score
 }
 
@@ -372,14 +1958,14 @@ func fuzzyScore(text, query string, initial float64) (float64, []int, bool) {
 	if query == "" {
 		return initial, nil, true
 	}
-	textLower := strings.ToLower(text)
-	queryLower := strings.ToLower(query)
+	textLower := []rune(strings.ToLower(text))
+	queryLower := []rune(strings.ToLower(query))
 	pos := 0
 	last := -1
 	score := initial
 	highlights := make([]int, 0, len(queryLower))
 	for _, qc := range queryLower {
-		idx := strings.IndexRune(textLower[pos:], qc)
+		idx := slices.Index(textLower[pos:], qc)
 		if idx < 0 {
 			return 0, nil, false
 		}
@@ -397,12 +1983,12 @@ func fuzzyScore(text, query string, initial float64) (float64, []int, bool) {
 		pos = found + 1
 	}
 	score *= float64(len(queryLower)) / float64(last+1)
-	score *= 10.0 / (float64(len(text)) + 10.0)
+	score *= 10.0 / (float64(len(textLower)) + 10.0)
 	return score, highlights, true
 }
 
-func isWordChar(ch byte) bool {
-	return (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9')
+func isWordChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch)
 }
 
 func mathSqrt(x float64) float64 {
@@ -416,241 +2002,2536 @@ func mathSqrt(x float64) float64 {
 	return z
 }
 
-func (m selectorModel) Init() tea.Cmd { return nil }
+func (m selectorModel) Init() tea.Cmd { return waitGitStatus(m.gitUpdates) }
 
-func (m *selectorModel) refresh() {
-	filtered := make([]scoredEntry, 0, len(m.entries))
-	for _, e := range m.entries {
-		score, highlights, ok := fuzzyScore(e.Name, m.query, baseScore(e))
-		if !ok {
-			continue
-		}
-		filtered = append(filtered, scoredEntry{entry: e, Score: score, Highlights: highlights})
+// waitGitStatus delivers the next git inspection result; Update asks for the
+// next one each time, so results stream in until the channel is closed.
+func waitGitStatus(updates <-chan gitStatusMsg) tea.Cmd {
+	if updates == nil {
+		return nil
 	}
-	slices.SortFunc(filtered, func(a, b scoredEntry) int {
-		if a.Score > b.Score {
-			return -1
-		}
-		if a.Score < b.Score {
-			return 1
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
 		}
-		return strings.Compare(a.Name, b.Name)
-	})
-	m.filtered = filtered
-	maxCursor := len(m.filtered)
-	if m.cursor > maxCursor {
-		m.cursor = maxCursor
-	}
-	if m.cursor < 0 {
-		m.cursor = 0
+		return msg
 	}
 }
 
-func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		return m, nil
-	case tea.KeyMsg:
-		if m.deleteMode {
-			switch msg.Type {
-			case tea.KeyEsc:
-				m.deleteMode = false
-				m.deleteConfirm = ""
-				m.deleteTarget = ""
-			case tea.KeyBackspace:
-				if m.deleteConfirm != "" {
-					m.deleteConfirm = m.deleteConfirm[:len(m.deleteConfirm)-1]
-				}
-			case tea.KeyRunes:
-				var b strings.Builder
-				b.Grow(len(m.deleteConfirm) + len(msg.Runes))
-				b.WriteString(m.deleteConfirm)
-				for _, r := range msg.Runes {
-					if r == '\n' || r == '\r' {
-						continue
-					}
-					b.WriteRune(r)
+// inspectGitAll inspects paths with at most workers git processes at once.
+// Paths that are not git checkouts are skipped; the channel is closed when
+// all are done. Closing done stops the workers early, once the selector no
+// longer reads their results.
+func inspectGitAll(paths []string, workers int, done <-chan struct{}) <-chan gitStatusMsg {
+	jobs := make(chan string)
+	out := make(chan gitStatusMsg)
+	var wg sync.WaitGroup
+	for range min(workers, max(len(paths), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				info, ok := inspectGit(path)
+				if !ok {
+					continue
 				}
-				m.deleteConfirm = b.String()
-			case tea.KeyEnter:
-				if m.deleteConfirm == "YES" && m.deleteTarget != "" {
-					m.deleted = m.deleteTarget
-					return m, tea.Quit
+				select {
+				case out <- gitStatusMsg{path: path, info: info}:
+				case <-done:
+					return
 				}
 			}
-			return m, nil
+		}()
+	}
+	go func() {
+	feed:
+		for _, path := range paths {
+			select {
+			case jobs <- path:
+			case <-done:
+				break feed
+			}
 		}
+		close(jobs)
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// gitOrigin returns the URL of the origin remote of the checkout at path,
+// with any password redacted, or "" when there is none.
+func gitOrigin(path string) string {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return ""
+	}
+	out, err := exec.Command("git", "-C", path, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return ""
+	}
+	return stripControl(redactURI(strings.TrimSpace(string(out))))
+}
+
+// gitOrigins runs gitOrigin over paths, at most workers at once, keyed by
+// path.
+func gitOrigins(paths []string, workers int) map[string]string {
+	origins := make(map[string]string, len(paths))
+	sem := make(chan struct{}, workers)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if origin := gitOrigin(path); origin != "" {
+				mu.Lock()
+				origins[path] = origin
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return origins
+}
+
+// inspectGit reads branch, upstream divergence and dirty file count from
+// git status --porcelain=v2, plus the last commit time. ok is false when path
+// is not a git checkout.
+func inspectGit(path string) (gitInfo, bool) {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return gitInfo{}, false
+	}
+	out, err := exec.Command("git", "-C", path, "status", "--porcelain=v2", "--branch").Output()
+	if err != nil {
+		return gitInfo{}, false
+	}
+	var info gitInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			info.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			info.Upstream = true
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &info.Ahead, &info.Behind)
+		case strings.HasPrefix(line, "#"):
+		default:
+			info.Dirty++
+		}
+	}
+	if out, err := exec.Command("git", "-C", path, "log", "-1", "--format=%ct").Output(); err == nil {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			info.LastCommit = time.Unix(sec, 0)
+		}
+	}
+	return info, true
+}
+
+// gitLabel renders a gitInfo as "main ↑1 ↓2 ~3 2d": branch, commits ahead
+// and behind upstream, dirty files and the age of the last commit.
+func gitLabel(info gitInfo, now time.Time) string {
+	parts := []string{info.Branch}
+	if info.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", info.Ahead))
+	}
+	if info.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", info.Behind))
+	}
+	if info.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("~%d", info.Dirty))
+	}
+	if !info.LastCommit.IsZero() {
+		parts = append(parts, relativeTime(info.LastCommit, now))
+	}
+	return strings.Join(parts, " ")
+}
+
+// setQuery replaces the query and places the edit cursor at a rune index.
+func (m *selectorModel) setQuery(runes []rune, cursor int) {
+	m.query = string(runes)
+	m.queryCursor = min(max(cursor, 0), len(runes))
+	m.refresh()
+}
+
+// editQuery applies line-editor keys to the query. It reports whether the key
+// was consumed so list navigation can handle the rest.
+func (m *selectorModel) editQuery(msg tea.KeyMsg) bool {
+	runes, pos, ok := editLine([]rune(m.query), m.queryCursor, msg)
+	if !ok {
+		return false
+	}
+	if string(runes) != m.query {
+		m.setQuery(runes, pos)
+	} else {
+		m.queryCursor = pos
+	}
+	return true
+}
+
+// editLine applies a line-editor key to runes with the cursor at pos and
+// returns the new text and cursor. ok is false for keys it does not handle.
+func editLine(runes []rune, pos int, msg tea.KeyMsg) (_ []rune, _ int, ok bool) {
+	pos = min(max(pos, 0), len(runes))
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if pos > 0 {
+			return slices.Delete(runes, pos-1, pos), pos - 1, true
+		}
+	case tea.KeyDelete:
+		if pos < len(runes) {
+			return slices.Delete(runes, pos, pos+1), pos, true
+		}
+	case tea.KeyLeft, tea.KeyCtrlB:
+		return runes, max(pos-1, 0), true
+	case tea.KeyRight, tea.KeyCtrlF:
+		return runes, min(pos+1, len(runes)), true
+	case tea.KeyCtrlA:
+		return runes, 0, true
+	case tea.KeyCtrlE:
+		return runes, len(runes), true
+	case tea.KeyCtrlW:
+		start := wordStart(runes, pos)
+		return slices.Delete(runes, start, pos), start, true
+	case tea.KeyCtrlU:
+		return nil, 0, true
+	case tea.KeyRunes, tea.KeySpace:
+		if msg.Alt && len(msg.Runes) == 1 {
+			switch msg.Runes[0] {
+			case 'b':
+				pos = wordStart(runes, pos)
+			case 'f':
+				pos = wordEnd(runes, pos)
+			}
+			return runes, pos, true
+		}
+		insert := make([]rune, 0, len(msg.Runes))
+		for _, r := range msg.Runes {
+			if r == '\n' || r == '\r' {
+				continue
+			}
+			insert = append(insert, r)
+		}
+		return slices.Insert(runes, pos, insert...), pos + len(insert), true
+	default:
+		return runes, pos, false
+	}
+	return runes, pos, true
+}
+
+func wordStart(runes []rune, pos int) int {
+	for pos > 0 && !isWordChar(runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordChar(runes[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+func wordEnd(runes []rune, pos int) int {
+	for pos < len(runes) && !isWordChar(runes[pos]) {
+		pos++
+	}
+	for pos < len(runes) && isWordChar(runes[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// splitQuery pulls "#tag" and "tag:name" filters out of a query and returns
+// them with the rest, which is matched fuzzily against names. A bare "#" or
+// "tag:" still being typed filters nothing.
+func splitQuery(query string) (tags []string, rest string) {
+	var words []string
+	found := false
+	for _, word := range strings.Fields(query) {
+		if strings.HasPrefix(word, "#") || strings.HasPrefix(word, "tag:") {
+			if tag := normalizeTag(word); tag != "" {
+				tags = append(tags, tag)
+			}
+			found = true
+			continue
+		}
+		words = append(words, word)
+	}
+	if !found {
+		return nil, query
+	}
+	return tags, strings.Join(words, " ")
+}
+
+// hasTags reports whether e carries every filter tag; a filter matches any
+// tag it is a prefix of, so results narrow while the tag is being typed.
+func hasTags(e entry, filters []string) bool {
+	for _, f := range filters {
+		if !slices.ContainsFunc(e.Tags, func(tag string) bool { return strings.HasPrefix(tag, f) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// rankEntries scores entries against query, drops non-matches and sorts the
+// rest best first, pinned tries ahead of the others.
+func rankEntries(entries []entry, query string) []scoredEntry {
+	tags, query := splitQuery(query)
+	ranked := make([]scoredEntry, 0, len(entries))
+	for _, e := range entries {
+		if !hasTags(e, tags) {
+			continue
+		}
+		score, highlights, ok := fuzzyScore(e.Name, query, baseScore(e))
+		if !ok {
+			continue
+		}
+		ranked = append(ranked, scoredEntry{entry: e, Score: score, Highlights: highlights})
+	}
+	slices.SortFunc(ranked, func(a, b scoredEntry) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if a.Score > b.Score {
+			return -1
+		}
+		if a.Score < b.Score {
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ranked
+}
+
+func (m *selectorModel) refresh() {
+	m.filtered = rankEntries(m.entries, m.query)
+	if m.sortBySize {
+		slices.SortStableFunc(m.filtered, func(a, b scoredEntry) int {
+			return cmp.Compare(m.sizes[b.Path].Bytes, m.sizes[a.Path].Bytes)
+		})
+	}
+	m.moveCursor(0)
+}
+
+// measureSizes walks every entry in the background for the size column.
+func measureSizes(entries []entry) tea.Cmd {
+	entries = slices.Clone(entries)
+	return func() tea.Msg {
+		return sizesMsg{sizes: measureEntries(entries)}
+	}
+}
+
+// rowCount is the number of selectable rows: the filtered entries plus the
+// create row, which restore mode does not offer.
+func (m selectorModel) rowCount() int {
+	if m.restoring {
+		return len(m.filtered)
+	}
+	return len(m.filtered) + 1
+}
+
+// listRows reports how many rows of the list (entries plus the create row)
+// fit on screen. Zero means the terminal size is unknown and nothing is cut.
+func (m selectorModel) listRows() int {
+	if m.height <= 3 {
+		return 0
+	}
+	rows := m.height - 3
+	if m.pinnedRows() > 0 {
+		// The pinned header and the divider below the section.
+		rows = max(rows-2, 1)
+	}
+	return rows
+}
+
+// pinnedRows is the number of pinned tries leading the list, which are drawn
+// as their own section. Sorting by size mixes them in, so there is none then.
+func (m selectorModel) pinnedRows() int {
+	if m.sortBySize {
+		return 0
+	}
+	n := 0
+	for n < len(m.filtered) && m.filtered[n].Pinned {
+		n++
+	}
+	return n
+}
+
+func (m *selectorModel) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor > m.rowCount()-1 {
+		m.cursor = m.rowCount() - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.scrollToCursor()
+}
+
+func (m *selectorModel) scrollToCursor() {
+	rows := m.listRows()
+	if rows == 0 {
+		m.offset = 0
+		return
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	if maxOffset := max(m.rowCount()-rows, 0); m.offset > maxOffset {
+		m.offset = maxOffset
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scrollToCursor()
+		return m, m.previewCmd()
+	case gitStatusMsg:
+		if m.gitInfo == nil {
+			m.gitInfo = map[string]gitInfo{}
+		}
+		m.gitInfo[msg.path] = msg.info
+		return m, waitGitStatus(m.gitUpdates)
+	case deleteSummaryMsg:
+		if m.deleteMode && slices.Contains(m.deleteTargets, msg.path) {
+			m.deleteSummaries[msg.path] = msg.summary
+			return m, m.nextDeleteSummary()
+		}
+		return m, nil
+	case sizesMsg:
+		m.sizes = msg.sizes
+		m.sizing = false
+		m.refresh()
+		return m, nil
+	case previewMsg:
+		if m.previews == nil {
+			m.previews = map[string]*preview{}
+		}
+		m.previews[msg.path] = &msg.preview
+		return m, nil
+	case tea.KeyMsg:
+		if m.deleteMode {
+			switch msg.Type {
+			case tea.KeyEsc:
+				m.deleteMode = false
+				m.archiveMode = false
+				m.deleteConfirm = ""
+				m.deleteTargets = nil
+				m.deleteSummaries = nil
+			case tea.KeyBackspace:
+				if r := []rune(m.deleteConfirm); len(r) > 0 {
+					m.deleteConfirm = string(r[:len(r)-1])
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				runes := msg.Runes
+				if msg.Type == tea.KeySpace {
+					runes = []rune{' '}
+				}
+				var b strings.Builder
+				b.Grow(len(m.deleteConfirm) + len(runes))
+				b.WriteString(m.deleteConfirm)
+				for _, r := range runes {
+					if r == '\n' || r == '\r' {
+						continue
+					}
+					b.WriteRune(r)
+				}
+				m.deleteConfirm = b.String()
+			case tea.KeyEnter:
+				if m.deleteLoaded() && m.deleteConfirm == m.deleteConfirmation() && len(m.deleteTargets) > 0 {
+					if m.archiveMode {
+						m.archived = m.deleteTargets
+					} else {
+						m.deleted = m.deleteTargets
+					}
+					return m, tea.Quit
+				}
+			}
+			return m, nil
+		}
+
+		if m.renameMode {
+			switch {
+			case msg.Type == tea.KeyCtrlC:
+				m.cancelled = true
+				return m, tea.Quit
+			case msg.Type == tea.KeyEsc:
+				m.renameMode = false
+			case msg.Type == tea.KeyEnter:
+				m.applyRename()
+			default:
+				runes, pos, _ := editLine([]rune(m.renameInput), m.renameCursor, msg)
+				m.renameInput, m.renameCursor = string(runes), pos
+				m.renameErr = ""
+			}
+			return m, nil
+		}
+
+		if m.tagMode {
+			switch {
+			case msg.Type == tea.KeyCtrlC:
+				m.cancelled = true
+				return m, tea.Quit
+			case msg.Type == tea.KeyEsc:
+				m.tagMode = false
+			case msg.Type == tea.KeyEnter:
+				m.applyTags()
+			default:
+				runes, pos, _ := editLine([]rune(m.tagInput), m.tagCursor, msg)
+				m.tagInput, m.tagCursor = string(runes), pos
+			}
+			return m, nil
+		}
+
+		if m.templateMode {
+			switch {
+			case msg.Type == tea.KeyCtrlC:
+				m.cancelled = true
+				return m, tea.Quit
+			case key.Matches(msg, m.keys.Cancel):
+				m.templateMode = false
+				m.createTarget = ""
+			case key.Matches(msg, m.keys.Up):
+				m.templateCursor = max(m.templateCursor-1, 0)
+			case key.Matches(msg, m.keys.Down):
+				m.templateCursor = min(m.templateCursor+1, len(m.templates))
+			case key.Matches(msg, m.keys.Enter):
+				if m.templateCursor > 0 {
+					m.template = m.templates[m.templateCursor-1]
+				}
+				_ = os.MkdirAll(m.createTarget, 0o755)
+				m.selected = m.createTarget
+				m.created = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		switch {
+		case msg.Type == tea.KeyCtrlC, key.Matches(msg, m.keys.Cancel):
+			m.cancelled = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Mark):
+			if !m.restoring && m.cursor >= 0 && m.cursor < len(m.filtered) {
+				m.toggleMark(m.filtered[m.cursor].Path)
+				m.moveCursor(1)
+			}
+		case key.Matches(msg, m.keys.MarkAll):
+			// Ctrl+A keeps its line-start meaning until the cursor is at the
+			// start of the query.
+			if msg.Type == tea.KeyCtrlA && m.queryCursor > 0 {
+				m.queryCursor = 0
+				break
+			}
+			shown := make([]string, len(m.filtered))
+			for i, e := range m.filtered {
+				shown[i] = e.Path
+			}
+			if !m.restoring && len(shown) > 0 {
+				all := !slices.ContainsFunc(shown, func(path string) bool { return !m.marked[path] })
+				for _, path := range shown {
+					if all == m.marked[path] {
+						m.toggleMark(path)
+					}
+				}
+			}
+		case key.Matches(msg, m.keys.Delete):
+			if targets := m.actionTargets(); len(targets) > 0 {
+				return m, m.confirmBatch(targets, false)
+			}
+		case key.Matches(msg, m.keys.Archive):
+			if targets := m.actionTargets(); len(targets) > 0 {
+				return m, m.confirmBatch(targets, true)
+			}
+		case key.Matches(msg, m.keys.Tag):
+			if targets := m.actionTargets(); len(targets) > 0 {
+				m.tagMode = true
+				m.tagTargets = targets
+				m.tagInput = ""
+				m.tagCursor = 0
+			}
+		case key.Matches(msg, m.keys.Pin):
+			if !m.restoring && m.cursor >= 0 && m.cursor < len(m.filtered) {
+				m.togglePin(m.filtered[m.cursor].Path)
+			}
+		case key.Matches(msg, m.keys.Rename):
+			if !m.restoring && m.cursor >= 0 && m.cursor < len(m.filtered) {
+				e := m.filtered[m.cursor]
+				m.renameMode = true
+				m.renameTarget = e.Path
+				m.renamePrefix, m.renameInput = splitDatePrefix(e.Name)
+				m.renameCursor = len([]rune(m.renameInput))
+				m.renameErr = ""
+			}
+		case key.Matches(msg, m.keys.Up):
+			m.moveCursor(-1)
+		case key.Matches(msg, m.keys.Down):
+			m.moveCursor(1)
+		case key.Matches(msg, m.keys.PageUp):
+			m.moveCursor(-max(m.listRows(), 1))
+		case key.Matches(msg, m.keys.PageDown):
+			m.moveCursor(max(m.listRows(), 1))
+		case key.Matches(msg, m.keys.Home):
+			m.moveCursor(-m.cursor)
+		case key.Matches(msg, m.keys.End):
+			m.moveCursor(m.rowCount() - 1 - m.cursor)
+		case key.Matches(msg, m.keys.NextRoot):
+			if !m.restoring && len(m.roots) > 1 {
+				i := slices.Index(m.roots, m.basePath)
+				m.basePath = m.roots[(i+1)%len(m.roots)]
+			}
+		case key.Matches(msg, m.keys.Enter):
+			if !m.restoring && m.cursor == len(m.filtered) {
+				tags, rest := splitQuery(m.query)
+				m.createTags = tags
+				name := sanitizeName(rest)
+				if name == "" {
+					name = "new-try"
+				}
+				target := filepath.Join(m.basePath, datedName(name))
+				target = uniquePath(target)
+				if len(m.templates) > 0 {
+					m.templateMode = true
+					m.templateCursor = 0
+					m.createTarget = target
+					return m, nil
+				}
+				_ = os.MkdirAll(target, 0o755)
+				m.selected = target
+				m.created = true
+				return m, tea.Quit
+			}
+			if m.cursor >= 0 && m.cursor < len(m.filtered) {
+				m.selected = m.filtered[m.cursor].Path
+				return m, tea.Quit
+			}
+		case key.Matches(msg, m.keys.Preview):
+			m.showPreview = !m.showPreview
+		case key.Matches(msg, m.keys.Sizes):
+			// Cycle: size column, then sorted by size, then off.
+			switch {
+			case !m.showSizes:
+				m.showSizes = true
+			case !m.sortBySize:
+				m.sortBySize = true
+			default:
+				m.showSizes, m.sortBySize = false, false
+			}
+			m.refresh()
+			if m.showSizes && m.sizes == nil && !m.sizing {
+				m.sizing = true
+				return m, measureSizes(m.entries)
+			}
+		default:
+			m.editQuery(msg)
+		}
+	}
+	return m, m.previewCmd()
+}
+
+// previewCmd starts loading the preview of the highlighted try when the pane
+// is open and it is neither cached nor already loading.
+func (m *selectorModel) previewCmd() tea.Cmd {
+	if !m.showPreview || m.restoring || m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	path := m.filtered[m.cursor].Path
+	if _, ok := m.previews[path]; ok {
+		return nil
+	}
+	if m.previews == nil {
+		m.previews = map[string]*preview{}
+	}
+	m.previews[path] = nil
+	return loadPreview(path)
+}
+
+func loadPreview(path string) tea.Cmd {
+	return func() tea.Msg {
+		return previewMsg{path: path, preview: buildPreview(path)}
+	}
+}
+
+// buildPreview gathers a try's top-level entries (directories first), the
+// start of its README or NOTES file, and its git branch and last commit.
+func buildPreview(path string) preview {
+	var p preview
+	dirs, err := os.ReadDir(path)
+	if err != nil {
+		p.Err = err
+		return p
+	}
+	slices.SortStableFunc(dirs, func(a, b os.DirEntry) int {
+		if a.IsDir() != b.IsDir() {
+			if a.IsDir() {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name(), b.Name())
+	})
+	for _, d := range dirs {
+		name := d.Name()
+		if name == ".git" {
+			continue
+		}
+		if p.Readme == "" && !d.IsDir() && isNotesFile(name) {
+			p.Readme = name
+		}
+		if len(p.Tree) == previewTreeEntries {
+			p.More++
+			continue
+		}
+		if d.IsDir() {
+			name += "/"
+		}
+		p.Tree = append(p.Tree, stripControl(name))
+	}
+	if p.Readme != "" {
+		if f, err := os.Open(filepath.Join(path, p.Readme)); err == nil {
+			sc := bufio.NewScanner(f)
+			for len(p.Lines) < previewReadmeLines && sc.Scan() {
+				line := strings.ReplaceAll(strings.TrimRight(sc.Text(), " \t\r"), "\t", "    ")
+				p.Lines = append(p.Lines, stripControl(line))
+			}
+			f.Close()
+		}
+	}
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		git := func(args ...string) string {
+			out, err := exec.Command("git", append([]string{"-C", path}, args...)...).Output()
+			if err != nil {
+				return ""
+			}
+			return stripControl(strings.TrimSpace(string(out)))
+		}
+		p.Branch = git("rev-parse", "--abbrev-ref", "HEAD")
+		if p.Branch == "HEAD" {
+			p.Branch = "detached at " + git("rev-parse", "--short", "HEAD")
+		}
+		p.Commit = git("log", "-1", "--format=%s")
+		p.Source = gitOrigin(path)
+	}
+	return p
+}
+
+// stripControl removes control characters, so file names and README text
+// from a cloned repo cannot send escape sequences to the terminal.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\t' && (r < 0x20 || (r >= 0x7f && r < 0xa0)) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func loadDeleteSummary(path string) tea.Cmd {
+	return func() tea.Msg {
+		return deleteSummaryMsg{path: path, summary: inspectDelete(path)}
+	}
+}
+
+// inspectDelete measures a try and, for git checkouts, counts the work that
+// is not pushed anywhere.
+func inspectDelete(path string) deleteSummary {
+	var d deleteSummary
+	t := measurePaths([]string{path})[path]
+	d.Size, d.Files = t.Bytes, t.Files
+	info, ok := inspectGit(path)
+	if !ok {
+		return d
+	}
+	d.Git = true
+	d.Dirty = info.Dirty
+	git := func(args ...string) []string {
+		out, err := exec.Command("git", append([]string{"-C", path}, args...)...).Output()
+		if err != nil || len(bytes.TrimSpace(out)) == 0 {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+	// A linked worktree points back at the repository that owns it; its
+	// branches, stashes and other worktrees survive deleting it, so only
+	// commits reachable from nothing but its HEAD count.
+	if st, err := os.Stat(filepath.Join(path, ".git")); err == nil && !st.IsDir() {
+		if common := git("rev-parse", "--path-format=absolute", "--git-common-dir"); len(common) == 1 {
+			d.WorktreeOf = filepath.Dir(common[0])
+		}
+		if out := git("rev-list", "--count", "HEAD", "--not", "--branches", "--remotes"); len(out) == 1 {
+			d.Unpushed, _ = strconv.Atoi(out[0])
+		}
+		return d
+	}
+	// Every local branch counts, not just the one checked out; HEAD adds the
+	// commits of a detached checkout.
+	if out := git("rev-list", "--count", "HEAD", "--branches", "--not", "--remotes"); len(out) == 1 {
+		d.Unpushed, _ = strconv.Atoi(out[0])
+	}
+	d.Stashes = len(git("stash", "list"))
+	for _, line := range git("worktree", "list", "--porcelain") {
+		if strings.HasPrefix(line, "worktree ") {
+			d.Worktrees++
+		}
+	}
+	// The list includes the main working tree itself.
+	d.Worktrees = max(d.Worktrees-1, 0)
+	return d
+}
+
+// lines renders the summary for the delete confirmation view.
+func (d deleteSummary) lines() []string {
+	lines := []string{fmt.Sprintf("%s in %s", formatSize(d.Size), plural(d.Files, "file"))}
+	if !d.Git {
+		return lines
+	}
+	work := d.work()
+	if len(work) == 0 {
+		work = append(work, "clean, everything pushed")
+	}
+	lines = append(lines, "git: "+strings.Join(work, ", "))
+	if d.WorktreeOf != "" {
+		lines = append(lines, "worktree of "+d.WorktreeOf)
+	}
+	return lines
+}
+
+// work lists the kinds of work that deleting would lose, e.g.
+// "2 unpushed commits".
+func (d deleteSummary) work() []string {
+	var work []string
+	if d.Dirty > 0 {
+		work = append(work, plural(d.Dirty, "uncommitted change"))
+	}
+	if d.Unpushed > 0 {
+		work = append(work, plural(d.Unpushed, "unpushed commit"))
+	}
+	if d.Stashes > 0 {
+		work = append(work, plural(d.Stashes, "stash"))
+	}
+	if d.Worktrees > 0 {
+		work = append(work, plural(d.Worktrees, "linked worktree"))
+	}
+	return work
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "sh") {
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// templateHelp lists the keys the template picker responds to.
+func (m selectorModel) templateHelp() string {
+	relabel := func(b key.Binding, desc string) key.Binding {
+		return key.NewBinding(key.WithKeys(b.Keys()...), key.WithHelp(b.Help().Key, desc))
+	}
+	return m.help.ShortHelpView([]key.Binding{m.keys.Up, m.keys.Down, relabel(m.keys.Enter, "create"), relabel(m.keys.Cancel, "back")})
+}
+
+// deleteTitle names what the confirmation is about to do.
+func (m selectorModel) deleteTitle() string {
+	if len(m.deleteTargets) == 1 {
+		if m.archiveMode {
+			return "Archive try: " + filepath.Base(m.deleteTargets[0])
+		}
+		if m.hardDelete {
+			return "Delete try permanently: " + filepath.Base(m.deleteTargets[0])
+		}
+		return "Move try to trash: " + filepath.Base(m.deleteTargets[0])
+	}
+	switch {
+	case m.archiveMode:
+		return fmt.Sprintf("Archive %d tries", len(m.deleteTargets))
+	case m.hardDelete:
+		return fmt.Sprintf("Delete %d tries permanently", len(m.deleteTargets))
+	default:
+		return fmt.Sprintf("Move %d tries to trash", len(m.deleteTargets))
+	}
+}
+
+// actionTargets are the tries a delete, archive or tag applies to: the
+// marked ones in list order, or else the highlighted one.
+func (m selectorModel) actionTargets() []string {
+	if m.restoring {
+		return nil
+	}
+	if len(m.marked) > 0 {
+		var targets []string
+		for _, e := range m.entries {
+			if m.marked[e.Path] {
+				targets = append(targets, e.Path)
+			}
+		}
+		return targets
+	}
+	if m.cursor >= 0 && m.cursor < len(m.filtered) {
+		return []string{m.filtered[m.cursor].Path}
+	}
+	return nil
+}
+
+func (m selectorModel) isPinned(path string) bool {
+	return slices.ContainsFunc(m.entries, func(e entry) bool { return e.Path == path && e.Pinned })
+}
+
+func (m *selectorModel) toggleMark(path string) {
+	if m.marked[path] {
+		delete(m.marked, path)
+		return
+	}
+	if m.marked == nil {
+		m.marked = map[string]bool{}
+	}
+	m.marked[path] = true
+}
+
+// confirmBatch opens the delete confirmation for targets and starts
+// summarising them.
+func (m *selectorModel) confirmBatch(targets []string, archive bool) tea.Cmd {
+	m.deleteMode = true
+	m.archiveMode = archive
+	m.deleteConfirm = ""
+	m.deleteTargets = targets
+	m.deleteSummaries = map[string]deleteSummary{}
+	return m.nextDeleteSummary()
+}
+
+// nextDeleteSummary loads the first target not yet summarised. Targets are
+// inspected one at a time, so a large batch does not walk every tree at once.
+func (m selectorModel) nextDeleteSummary() tea.Cmd {
+	for _, path := range m.deleteTargets {
+		if _, ok := m.deleteSummaries[path]; !ok {
+			return loadDeleteSummary(path)
+		}
+	}
+	return nil
+}
+
+func (m selectorModel) deleteLoaded() bool {
+	return len(m.deleteSummaries) == len(m.deleteTargets)
+}
+
+// deleteLosesWork counts the targets whose summary says work would be lost.
+func (m selectorModel) deleteLosesWork() int {
+	n := 0
+	for _, d := range m.deleteSummaries {
+		if d.losesWork() {
+			n++
+		}
+	}
+	return n
+}
+
+// deleteConfirmation is what the user has to type to delete: YES normally,
+// the directory name when work would be lost, or "DELETE <n>" when a batch
+// would lose work. Archiving loses nothing, so YES always does.
+func (m selectorModel) deleteConfirmation() string {
+	if m.archiveMode || m.deleteLosesWork() == 0 {
+		return "YES"
+	}
+	if len(m.deleteTargets) == 1 {
+		return filepath.Base(m.deleteTargets[0])
+	}
+	return fmt.Sprintf("DELETE %d", len(m.deleteTargets))
+}
+
+// applyTags adds and removes the typed tags on every tag target and copies
+// the result onto the entries.
+func (m *selectorModel) applyTags() {
+	var add, remove []string
+	for _, word := range strings.Fields(m.tagInput) {
+		if rest, ok := strings.CutPrefix(word, "-"); ok {
+			remove = append(remove, rest)
+		} else {
+			add = append(add, word)
+		}
+	}
+	tags := map[string][]string{}
+	_ = updateMetadata(func(md *metadata) {
+		for _, path := range m.tagTargets {
+			t := md.get(path)
+			t.addTags(add...)
+			t.removeTags(remove...)
+			tags[path] = slices.Clone(t.Tags)
+		}
+	})
+	for i := range m.entries {
+		if t, ok := tags[m.entries[i].Path]; ok {
+			m.entries[i].Tags = t
+		}
+	}
+	m.tagMode = false
+	m.tagTargets = nil
+	m.refresh()
+}
+
+// isNotesFile matches README and NOTES files, with or without an extension.
+func isNotesFile(name string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	return base == "readme" || base == "notes"
+}
+
+// togglePin flips the pin on path, saves it and keeps the cursor on the try
+// as it moves into or out of the pinned section.
+func (m *selectorModel) togglePin(path string) {
+	pinned := false
+	for i := range m.entries {
+		if m.entries[i].Path == path {
+			m.entries[i].Pinned = !m.entries[i].Pinned
+			pinned = m.entries[i].Pinned
+		}
+	}
+	_ = updateMetadata(func(md *metadata) { md.get(path).Pinned = pinned })
+	m.refresh()
+	for i, e := range m.filtered {
+		if e.Path == path {
+			m.cursor = i
+			m.scrollToCursor()
+		}
+	}
+}
+
+// applyRename renames renameTarget to the edited name, keeping its date
+// prefix. A name already taken gets uniquePath's numeric suffix. On success
+// the cursor is left on the renamed try; on failure rename mode stays open
+// with the error shown.
+func (m *selectorModel) applyRename() {
+	name := sanitizeName(m.renameInput)
+	if name == "" {
+		m.renameErr = "name must not be empty"
+		return
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		m.renameErr = "name must not contain path separators"
+		return
+	}
+	target := filepath.Join(filepath.Dir(m.renameTarget), m.renamePrefix+name)
+	if target != m.renameTarget {
+		target = uniquePath(target)
+		if err := renameTry(m.renameTarget, target); err != nil {
+			m.renameErr = err.Error()
+			return
+		}
+		for i := range m.entries {
+			if m.entries[i].Path == m.renameTarget {
+				m.entries[i].Path = target
+				m.entries[i].Name = filepath.Base(target)
+			}
+		}
+		if m.marked[m.renameTarget] {
+			delete(m.marked, m.renameTarget)
+			m.marked[target] = true
+		}
+		m.refresh()
+		// Keep the renamed try in view, dropping a query it no longer matches.
+		if !slices.ContainsFunc(m.filtered, func(e scoredEntry) bool { return e.Path == target }) {
+			m.setQuery(nil, 0)
+		}
+		for i, e := range m.filtered {
+			if e.Path == target {
+				m.cursor = i
+				m.scrollToCursor()
+			}
+		}
+	}
+	m.renameMode = false
+	m.renameTarget = ""
+}
+
+func (m selectorModel) View() string {
+	var b strings.Builder
+	if m.renameMode {
+		b.WriteString(titleStyle.Render("Rename try: " + filepath.Base(m.renameTarget)))
+		b.WriteString("\n")
+		b.WriteString(promptStyle.Render("New name: "))
+		b.WriteString(subtleStyle.Render(m.renamePrefix))
+		b.WriteString(renderLine(m.renameInput, m.renameCursor))
+		b.WriteString("\n")
+		if m.renameErr != "" {
+			b.WriteString(dangerStyle.Render(m.renameErr))
+			b.WriteString("\n")
+		}
+		b.WriteString(subtleStyle.Render("enter rename • esc cancel"))
+		return b.String()
+	}
+	if m.tagMode {
+		title := fmt.Sprintf("Tag %d tries", len(m.tagTargets))
+		if len(m.tagTargets) == 1 {
+			title = "Tag try: " + filepath.Base(m.tagTargets[0])
+		}
+		b.WriteString(titleStyle.Render(title))
+		b.WriteString("\n")
+		b.WriteString(promptStyle.Render("Tags: "))
+		b.WriteString(renderLine(m.tagInput, m.tagCursor))
+		b.WriteString("\n")
+		b.WriteString(subtleStyle.Render("enter apply • -tag removes • esc cancel"))
+		return b.String()
+	}
+	if m.deleteMode {
+		b.WriteString(dangerStyle.Render(m.deleteTitle()))
+		b.WriteString("\n")
+		switch {
+		case !m.deleteLoaded():
+			progress := "  inspecting…"
+			if len(m.deleteTargets) > 1 {
+				progress += fmt.Sprintf(" %d/%d", len(m.deleteSummaries), len(m.deleteTargets))
+			}
+			b.WriteString(subtleStyle.Render(progress))
+			b.WriteString("\n")
+		case len(m.deleteTargets) == 1:
+			d := m.deleteSummaries[m.deleteTargets[0]]
+			for _, line := range d.lines() {
+				b.WriteString(subtleStyle.Render("  " + line))
+				b.WriteString("\n")
+			}
+			if d.losesWork() && !m.archiveMode {
+				b.WriteString(dangerStyle.Render("  This try has work that exists nowhere else."))
+				b.WriteString("\n")
+			}
+		default:
+			var size int64
+			files := 0
+			for _, path := range m.deleteTargets {
+				d := m.deleteSummaries[path]
+				size += d.Size
+				files += d.Files
+				line := "  " + filepath.Base(path) + "  " + formatSize(d.Size)
+				if m.isPinned(path) {
+					line = "  " + pinStyle.Render("★ pinned") + subtleStyle.Render(line)
+				} else {
+					line = subtleStyle.Render(line)
+				}
+				if d.losesWork() {
+					b.WriteString(line + "  " + dangerStyle.Render(strings.Join(d.work(), ", ")))
+				} else {
+					b.WriteString(line)
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString(subtleStyle.Render(fmt.Sprintf("  %s in %s", formatSize(size), plural(files, "file"))))
+			b.WriteString("\n")
+			if n := m.deleteLosesWork(); n > 0 && !m.archiveMode {
+				b.WriteString(dangerStyle.Render(fmt.Sprintf("  %d of these tries have work that exists nowhere else.", n)))
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(promptStyle.Render("Type " + m.deleteConfirmation() + " to confirm: "))
+		b.WriteString(confirmStyle.Render(m.deleteConfirm))
+		b.WriteString("\n")
+		b.WriteString(subtleStyle.Render(m.help.View(m.keys)))
+		return b.String()
+	}
+
+	if m.templateMode {
+		b.WriteString(titleStyle.Render("Template for " + filepath.Base(m.createTarget)))
+		b.WriteString("\n")
+		for i, name := range append([]string{"(none)"}, m.templates...) {
+			prefix := "  "
+			if i == m.templateCursor {
+				prefix = selectStyle.Render("→ ")
+			}
+			b.WriteString(prefix + name + "\n")
+		}
+		b.WriteString(subtleStyle.Render(m.templateHelp()))
+		return b.String()
+	}
+
+	if m.showPreview && !m.restoring && m.width >= previewMinWidth {
+		listWidth := m.width * 3 / 5
+		list := m
+		list.showPreview = false
+		list.width = listWidth
+		list.help.Width = listWidth
+		left := lipgloss.NewStyle().Width(listWidth).Render(list.View())
+		return lipgloss.JoinHorizontal(lipgloss.Top, left, m.renderPreview(m.width-listWidth, max(m.height-1, lipgloss.Height(left))))
+	}
+
+	title := "try » "
+	if m.restoring {
+		title = "restore » "
+	}
+	if m.prompt != "" {
+		title = m.prompt
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString(m.renderQuery())
+	b.WriteString("\n")
+	start, end := 0, m.rowCount()
+	if rows := m.listRows(); rows > 0 {
+		start = m.offset
+		end = min(start+rows, end)
+	}
+	pinned := m.pinnedRows()
+	for i := start; i < end; i++ {
+		if pinned > 0 && i == 0 {
+			b.WriteString(pinStyle.Render("★ Pinned") + "\n")
+		}
+		if pinned > 0 && i == pinned {
+			b.WriteString(subtleStyle.Render(strings.Repeat("─", max(m.width-2, 10))) + "\n")
+		}
+		pointer, mark := " ", " "
+		if i == m.cursor {
+			pointer = selectStyle.Render("→")
+		}
+		if i < len(m.filtered) && m.marked[m.filtered[i].Path] {
+			mark = selectStyle.Render("•")
+		}
+		prefix := pointer + mark
+		if i == len(m.filtered) {
+			label := "+ Create new"
+			if m.query != "" {
+				label += ": " + m.query
+			}
+			if len(m.roots) > 1 {
+				label += " in " + rootLabel(m.basePath)
+			}
+			if m.width > 2 {
+				label = ansi.Truncate(label, m.width-2, "…")
+			}
+			b.WriteString(prefix + createStyle.Render(label) + "\n")
+			continue
+		}
+		b.WriteString(prefix)
+		b.WriteString(m.renderEntry(m.filtered[i]))
+		b.WriteString("\n")
+	}
+	b.WriteString(subtleStyle.Render(m.position()))
+	if len(m.marked) > 0 {
+		b.WriteString("  " + selectStyle.Render(fmt.Sprintf("%d marked", len(m.marked))))
+	}
+	if m.notice != "" {
+		b.WriteString("  " + promptStyle.Render(m.notice))
+	}
+	b.WriteString("\n")
+	b.WriteString(subtleStyle.Render(m.help.View(m.keys)))
+	return b.String()
+}
+
+// renderPreview draws the preview pane for the highlighted try, width
+// columns wide (including its left border) and at most height lines tall.
+func (m selectorModel) renderPreview(width, height int) string {
+	var lines []string
+	switch {
+	case m.cursor < 0 || m.cursor >= len(m.filtered):
+		lines = append(lines, subtleStyle.Render("new try"))
+	default:
+		e := m.filtered[m.cursor]
+		lines = append(lines, titleStyle.Render(e.Name))
+		p, loaded := m.previews[e.Path]
+		switch {
+		case !loaded || p == nil:
+			lines = append(lines, subtleStyle.Render("loading…"))
+		case p.Err != nil:
+			lines = append(lines, dangerStyle.Render(p.Err.Error()))
+		default:
+			if p.Branch != "" {
+				git := "⎇ " + p.Branch
+				if p.Commit != "" {
+					git += " · " + p.Commit
+				}
+				lines = append(lines, promptStyle.Render(git))
+			}
+			if p.Source != "" {
+				lines = append(lines, subtleStyle.Render("from "+p.Source))
+			}
+			if e.Template != "" {
+				lines = append(lines, subtleStyle.Render("template "+e.Template))
+			}
+			lines = append(lines, "")
+			lines = append(lines, p.Tree...)
+			if p.More > 0 {
+				lines = append(lines, subtleStyle.Render(fmt.Sprintf("… %d more", p.More)))
+			}
+			if len(p.Tree) == 0 {
+				lines = append(lines, subtleStyle.Render("(empty)"))
+			}
+			if p.Readme != "" {
+				lines = append(lines, "", subtleStyle.Render("── "+p.Readme))
+				lines = append(lines, p.Lines...)
+			}
+		}
+	}
+	inner := max(width-3, 1)
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, inner, "…")
+	}
+	pane := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(subtleStyle.GetForeground()).
+		PaddingLeft(1).
+		Width(width - 1)
+	return pane.Render(strings.Join(lines, "\n"))
+}
+
+func (m selectorModel) renderQuery() string {
+	return renderLine(m.query, m.queryCursor)
+}
+
+// renderLine draws editable text with the cursor at rune index pos.
+func renderLine(text string, pos int) string {
+	runes := []rune(text)
+	pos = min(max(pos, 0), len(runes))
+	at := " "
+	after := ""
+	if pos < len(runes) {
+		at = string(runes[pos])
+		after = string(runes[pos+1:])
+	}
+	return confirmStyle.Render(string(runes[:pos])) + cursorStyle.Render(at) + confirmStyle.Render(after)
+}
+
+// renderEntry draws one list row (without the cursor prefix): the name with
+// matched characters highlighted, and a right-aligned "touched, score" column.
+// Pinned tries carry a marker.
+func (m selectorModel) renderEntry(e scoredEntry) string {
+	now := time.Now()
+	meta := fmt.Sprintf("%s, %.1f", relativeTime(e.Touched, now), e.Score)
+	if len(m.roots) > 1 {
+		meta = rootLabel(e.Root) + "  " + meta
+	}
+	meta = subtleStyle.Render(meta)
+	if info, ok := m.gitInfo[e.Path]; ok {
+		meta = gitStyle.Render(gitLabel(info, now)) + "  " + meta
+	}
+	if m.showSizes {
+		size := "…"
+		if t, ok := m.sizes[e.Path]; ok {
+			size = formatSize(t.Bytes)
+		}
+		meta = promptStyle.Render(size) + "  " + meta
+	}
+	name := highlightName(e.Name, e.Highlights)
+	if e.Pinned {
+		name = pinStyle.Render("★ ") + name
+	}
+	if extra := entryLabels(e.entry); extra != "" {
+		name += " " + subtleStyle.Render(extra)
+	}
+	if m.width <= 0 {
+		return name + "  " + meta
+	}
+	avail := max(m.width-2-lipgloss.Width(meta)-2, 1)
+	name = ansi.Truncate(name, avail, "…")
+	gap := max(m.width-2-lipgloss.Width(name)-lipgloss.Width(meta), 2)
+	return name + strings.Repeat(" ", gap) + meta
+}
+
+// entryLabels renders an entry's tags and description as "#a #b  text".
+func entryLabels(e entry) string {
+	labels := make([]string, 0, len(e.Tags)+1)
+	for _, tag := range e.Tags {
+		labels = append(labels, "#"+tag)
+	}
+	if e.Description != "" {
+		labels = append(labels, e.Description)
+	}
+	return strings.Join(labels, "  ")
+}
+
+// rootLabel shortens a root for display by replacing the home directory with ~.
+func rootLabel(root string) string {
+	if home, _ := os.UserHomeDir(); home != "" {
+		if rel, err := filepath.Rel(home, root); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return root
+}
+
+func highlightName(name string, highlights []int) string {
+	if len(highlights) == 0 {
+		return name
+	}
+	var b strings.Builder
+	for i, r := range []rune(name) {
+		if slices.Contains(highlights, i) {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func relativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+}
+
+func (m selectorModel) position() string {
+	if len(m.filtered) == 0 && m.restoring {
+		return "0/0"
+	}
+	if m.cursor >= len(m.filtered) {
+		return fmt.Sprintf("new/%d", len(m.filtered))
+	}
+	return fmt.Sprintf("%d/%d", m.cursor+1, len(m.filtered))
+}
+
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", path, i)
+		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+type selectorResult struct {
+	selected  string
+	created   bool
+	template  string
+	tags      []string
+	deleted   []string
+	archived  []string
+	cancelled bool
+}
+
+func newSelectorModel(roots []string, initialQuery string, entries []entry) selectorModel {
+	helpModel := help.New()
+	helpModel.ShowAll = false
+	return selectorModel{
+		basePath:    roots[0],
+		roots:       roots,
+		query:       initialQuery,
+		queryCursor: len([]rune(initialQuery)),
+		entries:     entries,
+		width:       80,
+		height:      24,
+		keys:        newSelectorKeyMap(),
+		help:        helpModel,
+	}
+}
+
+// applyMetadata copies pins, tags and descriptions onto entries.
+func applyMetadata(entries []entry) {
+	md, err := loadMetadata(metadataPath())
+	if err != nil {
+		return
+	}
+	for i := range entries {
+		if t := md.Tries[entries[i].Path]; t != nil {
+			entries[i].Pinned = t.Pinned
+			entries[i].Tags = t.Tags
+			entries[i].Description = t.Description
+			entries[i].Template = t.Template
+		}
+	}
+}
+
+func applyHistory(entries []entry) {
+	h, err := loadHistory(historyPath())
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for i := range entries {
+		entries[i].Frecency = h.frecency(entries[i].Path, now)
+	}
+}
+
+func runSelector(m selectorModel) (selectorResult, error) {
+	m.refresh()
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithInput(os.Stdin))
+	finalModel, err := p.Run()
+	if err != nil {
+		return selectorResult{}, err
+	}
+	fin := finalModel.(selectorModel)
+	return selectorResult{selected: fin.selected, created: fin.created, template: fin.template, tags: fin.createTags, deleted: fin.deleted, archived: fin.archived, cancelled: fin.cancelled}, nil
+}
+
+func cmdCD(args []string, roots []string, hardDelete bool) ([]string, bool, error) {
+	triesPath := roots[0]
+	searchTerm := strings.Join(args, " ")
+	parts := strings.Fields(searchTerm)
+	if len(parts) > 0 && isGitURI(parts[0]) {
+		cmds, err := cmdClone(parts, triesPath)
+		return cmds, false, err
+	}
+	if len(parts) > 0 && isWorktreeSource(parts[0]) {
+		cmds, err := cmdWorktree(parts, triesPath)
+		return cmds, false, err
+	}
+	entries, err := listAllEntries(roots)
+	if err != nil {
+		return nil, false, err
+	}
+	applyHistory(entries)
+	applyMetadata(entries)
+	m := newSelectorModel(roots, searchTerm, entries)
+	m.hardDelete = hardDelete
+	m.notice = gcNotice(entries, time.Now())
+	m.templates, _ = listTemplates()
+	if cfg.GitStatus {
+		paths := make([]string, len(entries))
+		for i, e := range entries {
+			paths[i] = e.Path
+		}
+		done := make(chan struct{})
+		defer close(done)
+		m.gitUpdates = inspectGitAll(paths, gitWorkers, done)
+	}
+	result, err := runSelector(m)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.cancelled || (result.selected == "" && len(result.deleted) == 0 && len(result.archived) == 0) {
+		return nil, true, nil
+	}
+	if len(result.archived) > 0 {
+		var cmds []string
+		for _, path := range result.archived {
+			root := filepath.Dir(path)
+			cmds = append(cmds, scriptArchive(path, root, archivePathFor(root, filepath.Base(path)))...)
+		}
+		return cmds, false, nil
+	}
+	if len(result.deleted) > 0 {
+		var cmds []string
+		now := time.Now()
+		for _, path := range result.deleted {
+			root := filepath.Dir(path)
+			cmds = append(cmds, scriptHook("on_delete", path, "")...)
+			if hardDelete {
+				cmds = append(cmds, scriptDelete(path, root)...)
+			} else {
+				cmds = append(cmds, scriptTrash(path, root, now)...)
+			}
+		}
+		return cmds, false, nil
+	}
+	if result.template != "" {
+		if err := applyTemplate(templatePath(result.template), result.selected, templateVars(result.selected)); err != nil {
+			return nil, false, fmt.Errorf("applying template %s: %w", result.template, err)
+		}
+	}
+	if result.created && (result.template != "" || len(result.tags) > 0) {
+		_ = updateMetadata(func(md *metadata) {
+			t := md.get(result.selected)
+			t.Template = result.template
+			t.addTags(result.tags...)
+		})
+	}
+	_ = recordVisit(result.selected)
+	event := "on_enter"
+	if result.created {
+		event = "on_create"
+	}
+	return append(scriptCD(result.selected), scriptHook(event, result.selected, "")...), false, nil
+}
+
+func cmdNew(args []string, triesPath string) ([]string, error) {
+	args, tmpl := extractOption(args, "--template")
+	tags, rest := splitQuery(strings.Join(args, " "))
+	name := sanitizeName(rest)
+	if name == "" {
+		name = "new-try"
+	}
+	target := uniquePath(filepath.Join(triesPath, datedName(name)))
+	cmds := scriptMkdirCD(target)
+	if tmpl != "" {
+		src := templatePath(tmpl)
+		if st, err := os.Stat(src); err != nil || !st.IsDir() {
+			available, _ := listTemplates()
+			return nil, fmt.Errorf("unknown template %q (available: %s)", tmpl, strings.Join(available, ", "))
+		}
+		if err := applyTemplate(src, target, templateVars(target)); err != nil {
+			return nil, fmt.Errorf("applying template %s: %w", tmpl, err)
+		}
+		cmds = scriptCD(target)
+	}
+	// Only record the try once nothing can fail any more.
+	_ = recordVisit(target)
+	if tmpl != "" || len(tags) > 0 {
+		_ = updateMetadata(func(md *metadata) {
+			t := md.get(target)
+			t.Template = tmpl
+			t.addTags(tags...)
+		})
+	}
+	return append(cmds, scriptHook("on_create", target, "")...), nil
+}
+
+func cmdRestore(args []string, roots []string) ([]string, bool, error) {
+	var trashRoots []string
+	for _, root := range roots {
+		if _, err := os.Stat(filepath.Join(root, trashDirName)); err == nil {
+			trashRoots = append(trashRoots, filepath.Join(root, trashDirName))
+		}
+	}
+	if len(trashRoots) == 0 {
+		return nil, false, errors.New("trash is empty")
+	}
+	entries, err := listAllEntries(trashRoots)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(entries) == 0 {
+		return nil, false, errors.New("trash is empty")
+	}
+	m := newSelectorModel(trashRoots, strings.Join(args, " "), entries)
+	m.restoring = true
+	result, err := runSelector(m)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.cancelled || result.selected == "" {
+		return nil, true, nil
+	}
+	name, _, _ := untrashName(filepath.Base(result.selected))
+	target := uniquePath(filepath.Join(filepath.Dir(filepath.Dir(result.selected)), name))
+	_ = recordVisit(target)
+	return append(scriptRestore(result.selected, target), scriptHook("on_enter", target, "")...), false, nil
+}
+
+// cmdArchive packs the try named by args (a name in one of the roots, or a
+// path) into its root's archive directory.
+func cmdArchive(args []string, roots []string) ([]string, error) {
+	if len(args) != 1 {
+		return nil, errors.New("usage: try archive <name>")
+	}
+	path, err := findTry(args[0], roots)
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Dir(path)
+	return scriptArchive(path, root, archivePathFor(root, filepath.Base(path))), nil
+}
+
+// findTry resolves a try given by exact name in any root, or by path.
+func findTry(name string, roots []string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		path, err := filepath.Abs(mustExpand(name))
+		if err != nil {
+			return "", err
+		}
+		if st, err := os.Stat(path); err != nil || !st.IsDir() {
+			return "", fmt.Errorf("no try at %s", path)
+		}
+		return path, nil
+	}
+	var found []string
+	for _, root := range roots {
+		path := filepath.Join(root, name)
+		if st, err := os.Stat(path); err == nil && st.IsDir() && !strings.HasPrefix(name, ".") {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no try named %q", name)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("%q exists in several roots: %s", name, strings.Join(found, ", "))
+	}
+}
+
+// cmdPin implements try pin|unpin <name>.
+func cmdPin(args []string, roots []string, pinned bool, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: try pin|unpin <name>")
+	}
+	path, err := findTry(args[0], roots)
+	if err != nil {
+		return err
+	}
+	if err := updateMetadata(func(md *metadata) { md.get(path).Pinned = pinned }); err != nil {
+		return err
+	}
+	verb := "Unpinned"
+	if pinned {
+		verb = "Pinned"
+	}
+	fmt.Fprintf(w, "%s %s\n", verb, filepath.Base(path))
+	return nil
+}
+
+// cmdTag implements try tag add|rm <name> <tag>... and try tag ls [name].
+// ls without a name counts how many tries carry each tag.
+func cmdTag(args []string, roots []string, w io.Writer) error {
+	usage := errors.New("usage: try tag add|rm <name> <tag>... | try tag ls [name]")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "add", "rm":
+		if len(args) < 3 {
+			return usage
+		}
+		path, err := findTry(args[1], roots)
+		if err != nil {
+			return err
+		}
+		var tags []string
+		err = updateMetadata(func(md *metadata) {
+			t := md.get(path)
+			if args[0] == "add" {
+				t.addTags(args[2:]...)
+			} else {
+				t.removeTags(args[2:]...)
+			}
+			tags = t.Tags
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: %s\n", filepath.Base(path), strings.Join(tags, ", "))
+		return nil
+	case "ls":
+		md, err := loadMetadata(metadataPath())
+		if err != nil {
+			return err
+		}
+		if len(args) > 1 {
+			path, err := findTry(args[1], roots)
+			if err != nil {
+				return err
+			}
+			if t := md.Tries[path]; t != nil {
+				for _, tag := range t.Tags {
+					fmt.Fprintln(w, tag)
+				}
+			}
+			return nil
+		}
+		counts := map[string]int{}
+		for p, t := range md.Tries {
+			// Trashed, archived or never created tries are not counted.
+			if _, err := os.Stat(p); err != nil {
+				continue
+			}
+			for _, tag := range t.Tags {
+				counts[tag]++
+			}
+		}
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		slices.Sort(tags)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, tag := range tags {
+			fmt.Fprintf(tw, "%s\t%d\n", tag, counts[tag])
+		}
+		return tw.Flush()
+	default:
+		return usage
+	}
+}
+
+// cmdDescribe implements try describe <name> [text]; without text it clears
+// the description.
+func cmdDescribe(args []string, roots []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: try describe <name> [text]")
+	}
+	path, err := findTry(args[0], roots)
+	if err != nil {
+		return err
+	}
+	desc := strings.Join(strings.Fields(strings.Join(args[1:], " ")), " ")
+	return updateMetadata(func(md *metadata) { md.get(path).Description = desc })
+}
+
+// cmdUnarchive opens the archive view and unpacks the chosen try back into
+// its root.
+func cmdUnarchive(args []string, roots []string) ([]string, bool, error) {
+	entries, err := listArchives(roots)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(entries) == 0 {
+		return nil, false, errors.New("no archived tries")
+	}
+	var archiveRoots []string
+	for _, root := range roots {
+		archiveRoots = append(archiveRoots, filepath.Join(root, archiveDirName))
+	}
+	m := newSelectorModel(archiveRoots, strings.Join(args, " "), entries)
+	m.restoring = true
+	m.prompt = "unarchive » "
+	result, err := runSelector(m)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.cancelled || result.selected == "" {
+		return nil, true, nil
+	}
+	name, _ := archiveBaseName(filepath.Base(result.selected))
+	target := uniquePath(filepath.Join(filepath.Dir(filepath.Dir(result.selected)), name))
+	_ = recordVisit(target)
+	return append(scriptUnarchive(result.selected, target), scriptHook("on_enter", target, "")...), false, nil
+}
+
+// trashCandidates lists trashed tries that were trashed more than olderThan
+// ago. Entries without a trash stamp fall back to their mtime.
+func trashCandidates(triesPath string, olderThan time.Duration, now time.Time) ([]string, error) {
+	dirs, err := os.ReadDir(filepath.Join(triesPath, trashDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, d := range dirs {
+		full := filepath.Join(triesPath, trashDirName, d.Name())
+		_, at, ok := untrashName(d.Name())
+		if !ok {
+			info, err := d.Info()
+			if err != nil {
+				continue
+			}
+			at = info.ModTime()
+		}
+		if now.Sub(at) >= olderThan {
+			paths = append(paths, full)
+		}
+	}
+	return paths, nil
+}
+
+func cmdTrash(args []string, roots []string) ([]string, error) {
+	args, olderThan := extractOption(args, "--older-than")
+	if len(args) == 0 || args[0] != "empty" {
+		return nil, errors.New("usage: try trash empty [--older-than 30d]")
+	}
+	age := time.Duration(0)
+	if olderThan != "" {
+		var err error
+		if age, err = parseAge(olderThan); err != nil {
+			return nil, err
+		}
+	}
+	var paths []string
+	for _, root := range roots {
+		found, err := trashCandidates(root, age, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, found...)
+	}
+	return scriptPurge(paths), nil
+}
+
+type gcCandidate struct {
+	entry
+	LastUsed time.Time
+	Size     int64
+	// Skip says why the candidate is kept; empty means it is collected.
+	Skip string
+}
+
+// lastUsed is the latest of an entry's mtime, creation time and last visit.
+func lastUsed(e entry, h *history) time.Time {
+	last := e.Touched
+	for _, t := range []time.Time{e.Created, h.last(e.Path)} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// staleEntries returns the entries not used for at least age, oldest first.
+func staleEntries(entries []entry, h *history, age time.Duration, now time.Time) []gcCandidate {
+	var stale []gcCandidate
+	for _, e := range entries {
+		if last := lastUsed(e, h); now.Sub(last) >= age {
+			stale = append(stale, gcCandidate{entry: e, LastUsed: last})
+		}
+	}
+	slices.SortFunc(stale, func(a, b gcCandidate) int { return a.LastUsed.Compare(b.LastUsed) })
+	return stale
+}
+
+// gitDirty reports whether a try is a git checkout with uncommitted or
+// untracked changes. A failing git status counts as dirty so gc errs on the
+// side of keeping work.
+func gitDirty(path string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return false, nil
+	}
+	out, err := exec.Command("git", "-C", path, "status", "--porcelain").Output()
+	if err != nil {
+		return true, err
+	}
+	return len(bytes.TrimSpace(out)) > 0, nil
+}
 
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
-			m.cancelled = true
-			return m, tea.Quit
-		case tea.KeyCtrlD:
-			if m.cursor >= 0 && m.cursor < len(m.filtered) {
-				m.deleteMode = true
-				m.deleteConfirm = ""
-				m.deleteTarget = m.filtered[m.cursor].Path
-			}
-		case tea.KeyUp, tea.KeyCtrlP:
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case tea.KeyDown, tea.KeyCtrlN:
-			if m.cursor < len(m.filtered) {
-				m.cursor++
-			}
-		case tea.KeyBackspace:
-			if m.query != "" {
-				m.query = m.query[:len(m.query)-1]
-				m.refresh()
-			}
-		case tea.KeyRunes:
-			for _, r := range msg.Runes {
-				if r == '\n' || r == '\r' {
-					continue
-				}
-				m.query += string(r)
-			}
-			m.refresh()
-		case tea.KeyEnter:
-			if m.cursor == len(m.filtered) {
-				name := sanitizeName(m.query)
-				if name == "" {
-					name = "new-try"
-				}
-				target := filepath.Join(m.basePath, time.Now().Format("2006-01-02")+"-"+name)
-				target = uniquePath(target)
-				_ = os.MkdirAll(target, 0o755)
-				m.selected = target
-				return m, tea.Quit
-			}
-			if m.cursor >= 0 && m.cursor < len(m.filtered) {
-				m.selected = m.filtered[m.cursor].Path
-				return m, tea.Quit
-			}
+// cmdGC implements try gc [--untouched-for 90d] [--dry-run] [--archive]. The
+// report goes to w (stderr, so the shell wrapper only evals the script); the
+// returned script trashes or archives the candidates.
+func cmdGC(args []string, roots []string, w io.Writer) ([]string, error) {
+	args, untouchedFor := extractOption(args, "--untouched-for")
+	args, dryRun := extractFlag(args, "--dry-run")
+	args, archive := extractFlag(args, "--archive")
+	if len(args) > 0 {
+		return nil, errors.New("usage: try gc [--untouched-for 90d] [--dry-run] [--archive]")
+	}
+	if untouchedFor == "" {
+		untouchedFor = cfg.GC.UntouchedFor
+	}
+	if untouchedFor == "" {
+		return nil, errors.New("try gc needs --untouched-for or gc.untouched_for in the config")
+	}
+	age, err := parseAge(untouchedFor)
+	if err != nil {
+		return nil, err
+	}
+	action := cfg.GC.Action
+	if archive {
+		action = "archive"
+	}
+	entries, err := listAllEntries(roots)
+	if err != nil {
+		return nil, err
+	}
+	applyMetadata(entries)
+	h, err := loadHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	candidates := staleEntries(entries, h, age, now)
+	if len(candidates) == 0 {
+		fmt.Fprintf(w, "No tries untouched for %s.\n", untouchedFor)
+		return nil, nil
+	}
+
+	var cmds []string
+	var freed int64
+	collected := 0
+	paths := make([]string, len(candidates))
+	for i, c := range candidates {
+		paths[i] = c.Path
+	}
+	sizes := measurePaths(paths)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tUNTOUCHED\tACTION")
+	for i := range candidates {
+		c := &candidates[i]
+		c.Size = sizes[c.Path].Bytes
+		if c.Pinned {
+			c.Skip = "skip: pinned"
+		} else if dirty, err := gitDirty(c.Path); err != nil {
+			c.Skip = "skip: git status failed"
+		} else if dirty {
+			c.Skip = "skip: uncommitted changes"
+		}
+		status := action
+		if c.Skip != "" {
+			status = c.Skip
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, formatSize(c.Size), relativeTime(c.LastUsed, now), status)
+		if c.Skip != "" {
+			continue
+		}
+		collected++
+		freed += c.Size
+		if action == "archive" {
+			cmds = append(cmds, scriptArchive(c.Path, c.Root, archivePathFor(c.Root, c.Name))...)
+		} else {
+			cmds = append(cmds, scriptTrash(c.Path, c.Root, now)...)
 		}
 	}
-	return m, nil
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	verb := "Collecting"
+	if dryRun {
+		verb = "Would collect"
+		cmds = nil
+	}
+	fmt.Fprintf(w, "%s %d of %d tries untouched for %s (%s).\n", verb, collected, len(candidates), untouchedFor, formatSize(freed))
+	return cmds, nil
 }
 
-func (m selectorModel) View() string {
-	var b strings.Builder
-	if m.deleteMode {
-		target := filepath.Base(m.deleteTarget)
-		b.WriteString(dangerStyle.Render("Delete try: " + target))
-		b.WriteString("\n")
-		b.WriteString(promptStyle.Render("Type YES to confirm: "))
-		b.WriteString(confirmStyle.Render(m.deleteConfirm))
-		b.WriteString("\n")
-		b.WriteString(subtleStyle.Render(m.help.View(m.keys)))
-		return b.String()
+// gcNotice tells the selector how many tries the configured gc policy would
+// collect, without the slower size and git checks try gc makes.
+func gcNotice(entries []entry, now time.Time) string {
+	if cfg.GC.UntouchedFor == "" {
+		return ""
+	}
+	age, err := parseAge(cfg.GC.UntouchedFor)
+	if err != nil {
+		return ""
+	}
+	h, err := loadHistory(historyPath())
+	if err != nil {
+		return ""
+	}
+	n := 0
+	for _, c := range staleEntries(entries, h, age, now) {
+		if !c.Pinned {
+			n++
+		}
 	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d tries untouched for %s · try gc --dry-run", n, cfg.GC.UntouchedFor)
+}
 
-	b.WriteString(titleStyle.Render("try » "))
-	if m.query == "" {
-		b.WriteString("\n")
-	} else {
-		b.WriteString(confirmStyle.Render(m.query))
-		b.WriteString("\n")
+type mirror struct {
+	Name string
+	Path string
+	Size int64
+	Used time.Time
+}
+
+// listMirrors finds the bare mirrors under dir; the mirror directory's mtime
+// is bumped on every update, so it doubles as the last-used time.
+func listMirrors(dir string) ([]mirror, error) {
+	var mirrors []mirror
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !strings.HasSuffix(d.Name(), ".git") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		mirrors = append(mirrors, mirror{
+			Name: filepath.ToSlash(strings.TrimSuffix(rel, ".git")),
+			Path: path,
+			Used: info.ModTime(),
+		})
+		return filepath.SkipDir
+	})
+	if err != nil || len(mirrors) == 0 {
+		return mirrors, err
 	}
-	maxRows := len(m.filtered)
-	if m.height > 4 && maxRows > m.height-4 {
-		maxRows = m.height - 4
+	paths := make([]string, len(mirrors))
+	for i, m := range mirrors {
+		paths[i] = m.Path
 	}
-	for i := 0; i < maxRows; i++ {
-		prefix := "  "
-		if i == m.cursor {
-			prefix = selectStyle.Render("→ ")
+	sizes := measurePaths(paths)
+	for i := range mirrors {
+		mirrors[i].Size = sizes[mirrors[i].Path].Bytes
+	}
+	return mirrors, nil
+}
+
+// dirUsage is one directory's entry in the size cache: the bytes and count of
+// the regular files directly in it, and its subdirectories. A directory's
+// mtime changes whenever an entry is added, removed or renamed, so the entry
+// stays valid while ModTime matches. A file that grows in place leaves the
+// mtime alone and keeps its cached size until something else touches the
+// directory.
+type dirUsage struct {
+	ModTime time.Time `json:"mtime"`
+	Bytes   int64     `json:"bytes"`
+	Files   int       `json:"files"`
+	Subdirs []string  `json:"subdirs,omitempty"`
+}
+
+// sizeCache maps directory paths to their dirUsage. Entries seen by the
+// current run replace the loaded ones when it is saved.
+type sizeCache struct {
+	mu     sync.Mutex
+	Dirs   map[string]dirUsage `json:"dirs"`
+	seen   map[string]dirUsage
+	walked []string
+}
+
+func sizeCachePath() string {
+	return filepath.Join(cacheDir(), "sizes.json")
+}
+
+// loadSizeCache reads the size cache; a missing or unreadable cache is
+// simply empty.
+func loadSizeCache(path string) *sizeCache {
+	c := &sizeCache{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, c)
+	}
+	if c.Dirs == nil {
+		c.Dirs = map[string]dirUsage{}
+	}
+	c.seen = map[string]dirUsage{}
+	return c
+}
+
+// save writes the entries seen by this run over the loaded ones. A loaded
+// entry under a path walked this run that was not seen is gone. Entries
+// left by other runs (tries measured one at a time, mirrors) are kept while
+// the top of their tree still exists, so deleted tries drop out.
+func (c *sizeCache) save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	topOf := func(dir string) string {
+		for {
+			parent := filepath.Dir(dir)
+			if _, ok := c.Dirs[parent]; !ok || parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	exists := map[string]bool{}
+	dirs := make(map[string]dirUsage, len(c.Dirs))
+	for dir, u := range c.Dirs {
+		if _, ok := c.seen[dir]; ok || slices.ContainsFunc(c.walked, func(w string) bool { return isUnder(dir, w) }) {
+			continue
+		}
+		top := topOf(dir)
+		ok, checked := exists[top]
+		if !checked {
+			_, err := os.Lstat(top)
+			ok = err == nil
+			exists[top] = ok
+		}
+		if ok {
+			dirs[dir] = u
 		}
-		b.WriteString(prefix)
-		b.WriteString(m.filtered[i].Name)
-		b.WriteString("\n")
 	}
-	createPrefix := "  "
-	if m.cursor == len(m.filtered) {
-		createPrefix = selectStyle.Render("→ ")
+	for dir, u := range c.seen {
+		dirs[dir] = u
 	}
-	label := "+ Create new"
-	if m.query != "" {
-		label += ": " + m.query
+	return writeJSON(path, &sizeCache{Dirs: dirs})
+}
+
+// isUnder reports whether path is dir or inside it.
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// usage returns dir's cached entry when its mtime still matches, reading the
+// directory otherwise.
+func (c *sizeCache) usage(dir string, modTime time.Time) dirUsage {
+	c.mu.Lock()
+	u, ok := c.Dirs[dir]
+	c.mu.Unlock()
+	if !ok || !u.ModTime.Equal(modTime) {
+		u = readDirUsage(dir, modTime)
 	}
-	b.WriteString(createPrefix + createStyle.Render(label) + "\n")
-	b.WriteString(subtleStyle.Render(m.help.View(m.keys)))
-	return b.String()
+	c.mu.Lock()
+	c.seen[dir] = u
+	c.mu.Unlock()
+	return u
 }
 
-func uniquePath(path string) string {
-	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
-		return path
+// readDirUsage lists dir, adding up its regular files. Symlinks are neither
+// counted nor followed.
+func readDirUsage(dir string, modTime time.Time) dirUsage {
+	u := dirUsage{ModTime: modTime}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return u
 	}
-	for i := 2; ; i++ {
-		candidate := fmt.Sprintf("%s-%d", path, i)
-		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
-			return candidate
+	for _, e := range entries {
+		switch {
+		case e.Type()&fs.ModeSymlink != 0:
+		case e.IsDir():
+			u.Subdirs = append(u.Subdirs, e.Name())
+		case e.Type().IsRegular():
+			if info, err := e.Info(); err == nil {
+				u.Bytes += info.Size()
+				u.Files++
+			}
 		}
 	}
+	return u
 }
 
-type selectorResult struct {
-	selected  string
-	deleted   string
-	cancelled bool
+// usageTotal is the recursive size of a directory tree.
+type usageTotal struct {
+	Bytes int64
+	Files int
+}
+
+// sizeWalker measures directory trees with at most cap(sem) extra goroutines
+// walking at once; when none is free a subdirectory is walked inline.
+type sizeWalker struct {
+	sem   chan struct{}
+	cache *sizeCache
+}
+
+func (w *sizeWalker) walk(dir string) usageTotal {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return usageTotal{}
+	}
+	u := w.cache.usage(dir, info.ModTime())
+	total := usageTotal{Bytes: u.Bytes, Files: u.Files}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	add := func(t usageTotal) {
+		mu.Lock()
+		total.Bytes += t.Bytes
+		total.Files += t.Files
+		mu.Unlock()
+	}
+	for _, name := range u.Subdirs {
+		sub := filepath.Join(dir, name)
+		select {
+		case w.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-w.sem; wg.Done() }()
+				add(w.walk(sub))
+			}()
+		default:
+			add(w.walk(sub))
+		}
+	}
+	wg.Wait()
+	return total
+}
+
+// diskUsage measures each path with the cached concurrent walker, keyed by
+// path. A path only gets a goroutine once a worker slot is free, so no more
+// than workers walk at once however many paths there are.
+func diskUsage(paths []string, workers int, cache *sizeCache) map[string]usageTotal {
+	w := &sizeWalker{sem: make(chan struct{}, workers), cache: cache}
+	cache.mu.Lock()
+	cache.walked = append(cache.walked, paths...)
+	cache.mu.Unlock()
+	sizes := make(map[string]usageTotal, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		w.sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-w.sem; wg.Done() }()
+			t := w.walk(path)
+			mu.Lock()
+			sizes[path] = t
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return sizes
+}
+
+// measurePaths runs diskUsage over paths and saves the cache.
+func measurePaths(paths []string) map[string]usageTotal {
+	cache := loadSizeCache(sizeCachePath())
+	sizes := diskUsage(paths, duWorkers, cache)
+	_ = cache.save(sizeCachePath())
+	return sizes
+}
+
+// measureEntries measures each entry's try.
+func measureEntries(entries []entry) map[string]usageTotal {
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return measurePaths(paths)
+}
+
+type duItem struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Root  string `json:"root"`
+	Bytes int64  `json:"bytes"`
+	Files int    `json:"files"`
 }
 
-func runSelector(basePath, initialQuery string) (selectorResult, error) {
-	entries, err := listEntries(basePath)
+// cmdDu prints the largest tries and the total they use.
+func cmdDu(args []string, roots []string, w io.Writer) error {
+	args, topOpt := extractOption(args, "--top")
+	args, asJSON := extractFlag(args, "--json")
+	if len(args) > 0 {
+		return errors.New("usage: try du [--top 20] [--json]")
+	}
+	top := 20
+	if topOpt != "" {
+		n, err := strconv.Atoi(topOpt)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid --top: %s", topOpt)
+		}
+		top = n
+	}
+	entries, err := listAllEntries(roots)
 	if err != nil {
-		return selectorResult{}, err
+		return err
 	}
-	helpModel := help.New()
-	helpModel.ShowAll = false
-	m := selectorModel{
-		basePath: basePath,
-		query:    initialQuery,
-		entries:  entries,
-		width:    80,
-		height:   24,
-		keys:     newSelectorKeyMap(),
-		help:     helpModel,
+	sizes := measureEntries(entries)
+	items := make([]duItem, 0, len(entries))
+	var total usageTotal
+	for _, e := range entries {
+		t := sizes[e.Path]
+		total.Bytes += t.Bytes
+		total.Files += t.Files
+		items = append(items, duItem{Name: e.Name, Path: e.Path, Root: e.Root, Bytes: t.Bytes, Files: t.Files})
 	}
-	m.refresh()
-	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithInput(os.Stdin))
-	finalModel, err := p.Run()
+	slices.SortStableFunc(items, func(a, b duItem) int { return cmp.Compare(b.Bytes, a.Bytes) })
+	shown := items
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(shown)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tFILES\tNAME\tPATH")
+	for _, it := range shown {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", formatSize(it.Bytes), it.Files, it.Name, it.Path)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s in %s across %s\n", formatSize(total.Bytes), plural(total.Files, "file"), plural(len(items), "try"))
+	return nil
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// cmdCache implements try cache list and try cache prune [--older-than 30d].
+// Without --older-than, prune removes every mirror.
+func cmdCache(args []string, w io.Writer) error {
+	args, olderThan := extractOption(args, "--older-than")
+	if len(args) != 1 || (args[0] != "list" && args[0] != "prune") {
+		return errors.New("usage: try cache list|prune [--older-than 30d]")
+	}
+	dir := mirrorsDir()
+	mirrors, err := listMirrors(dir)
 	if err != nil {
-		return selectorResult{}, err
+		return err
 	}
-	fin := finalModel.(selectorModel)
-	return selectorResult{selected: fin.selected, deleted: fin.deleted, cancelled: fin.cancelled}, nil
+	now := time.Now()
+	if args[0] == "list" {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "MIRROR\tSIZE\tUSED")
+		for _, m := range mirrors {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", m.Name, formatSize(m.Size), relativeTime(m.Used, now))
+		}
+		return tw.Flush()
+	}
+	age := time.Duration(0)
+	if olderThan != "" {
+		if age, err = parseAge(olderThan); err != nil {
+			return err
+		}
+	}
+	for _, m := range mirrors {
+		if now.Sub(m.Used) < age {
+			continue
+		}
+		if err := os.RemoveAll(m.Path); err != nil {
+			return err
+		}
+		// Drop host and owner directories left empty; Remove fails harmlessly
+		// on the first one that still has mirrors in it.
+		parent := filepath.Dir(m.Path)
+		for parent != dir && os.Remove(parent) == nil {
+			parent = filepath.Dir(parent)
+		}
+		fmt.Fprintf(w, "Removed %s (%s)\n", m.Name, formatSize(m.Size))
+	}
+	return nil
 }
 
-func cmdCD(args []string, triesPath string) ([]string, bool, error) {
-	searchTerm := strings.Join(args, " ")
-	parts := strings.Fields(searchTerm)
-	if len(parts) > 0 && isGitURI(parts[0]) {
-		uri := parts[0]
-		custom := ""
-		if len(parts) > 1 {
-			custom = strings.Join(parts[1:], " ")
+type listItem struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Root        string    `json:"root"`
+	Created     time.Time `json:"created"`
+	Touched     time.Time `json:"touched"`
+	Score       float64   `json:"score"`
+	Pinned      bool      `json:"pinned"`
+	Tags        []string  `json:"tags,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Template    string    `json:"template,omitempty"`
+}
+
+func cmdList(args []string, roots []string, w io.Writer) error {
+	args, format := extractOption(args, "--format")
+	args, sortBy := extractOption(args, "--sort")
+	args, limitOpt := extractOption(args, "--limit")
+	args, nul := extractFlag(args, "-0")
+	if format == "" {
+		format = "table"
+		if nul {
+			format = "paths"
 		}
-		dirName, err := generateCloneDirectoryName(uri, custom)
-		if err != nil {
-			return nil, false, err
+	}
+	if nul && format != "paths" {
+		return errors.New("-0 requires --format paths")
+	}
+	limit := 0
+	if limitOpt != "" {
+		n, err := strconv.Atoi(limitOpt)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid --limit: %s", limitOpt)
 		}
-		return scriptClone(filepath.Join(triesPath, dirName), uri), false, nil
+		limit = n
 	}
-	result, err := runSelector(triesPath, searchTerm)
+
+	entries, err := listAllEntries(roots)
 	if err != nil {
-		return nil, false, err
+		return err
 	}
-	if result.cancelled || (result.selected == "" && result.deleted == "") {
-		return nil, true, nil
+	applyHistory(entries)
+	applyMetadata(entries)
+	ranked := rankEntries(entries, strings.Join(args, " "))
+	switch sortBy {
+	case "", "score":
+	case "name":
+		slices.SortStableFunc(ranked, func(a, b scoredEntry) int { return strings.Compare(a.Name, b.Name) })
+	case "created":
+		slices.SortStableFunc(ranked, func(a, b scoredEntry) int { return b.Created.Compare(a.Created) })
+	case "touched":
+		slices.SortStableFunc(ranked, func(a, b scoredEntry) int { return b.Touched.Compare(a.Touched) })
+	default:
+		return fmt.Errorf("unknown --sort: %s (want score, name, created or touched)", sortBy)
+	}
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		multi := len(roots) > 1
+		tagged := slices.ContainsFunc(ranked, func(e scoredEntry) bool { return len(e.Tags) > 0 })
+		header := []string{"NAME"}
+		if multi {
+			header = append(header, "ROOT")
+		}
+		if tagged {
+			header = append(header, "TAGS")
+		}
+		fmt.Fprintln(tw, strings.Join(append(header, "TOUCHED", "SCORE", "PATH"), "\t"))
+		now := time.Now()
+		for _, e := range ranked {
+			row := []string{e.Name}
+			if multi {
+				row = append(row, rootLabel(e.Root))
+			}
+			if tagged {
+				row = append(row, strings.Join(e.Tags, ","))
+			}
+			row = append(row, relativeTime(e.Touched, now), fmt.Sprintf("%.1f", e.Score), e.Path)
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case "json":
+		paths := make([]string, len(ranked))
+		for i, e := range ranked {
+			paths[i] = e.Path
+		}
+		origins := gitOrigins(paths, gitWorkers)
+		items := make([]listItem, 0, len(ranked))
+		for _, e := range ranked {
+			items = append(items, listItem{Name: e.Name, Path: e.Path, Root: e.Root, Created: e.Created, Touched: e.Touched, Score: e.Score, Pinned: e.Pinned, Tags: e.Tags, Description: e.Description, Source: origins[e.Path], Template: e.Template})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case "paths":
+		sep := "\n"
+		if nul {
+			sep = "\x00"
+		}
+		for _, e := range ranked {
+			fmt.Fprint(w, e.Path+sep)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown --format: %s (want table, json or paths)", format)
 	}
-	if result.deleted != "" {
-		return scriptDelete(result.deleted, triesPath), false, nil
+}
+
+// dispatch runs a subcommand and returns the shell script to emit. cancelled
+// reports that the user backed out of an interactive selector.
+func dispatch(command string, args []string, roots []string, hardDelete bool) ([]string, bool, error) {
+	switch command {
+	case "clone":
+		cmds, err := cmdClone(args, roots[0])
+		return cmds, false, err
+	case "worktree":
+		cmds, err := cmdWorktree(args, roots[0])
+		return cmds, false, err
+	case "new":
+		cmds, err := cmdNew(args, roots[0])
+		return cmds, false, err
+	case "restore":
+		return cmdRestore(args, roots)
+	case "archive":
+		cmds, err := cmdArchive(args, roots)
+		return cmds, false, err
+	case "unarchive":
+		return cmdUnarchive(args, roots)
+	case "gc":
+		cmds, err := cmdGC(args, roots, os.Stderr)
+		return cmds, false, err
+	case "trash":
+		cmds, err := cmdTrash(args, roots)
+		return cmds, false, err
+	default:
+		return cmdCD(append([]string{command}, args...), roots, hardDelete)
 	}
-	return scriptCD(result.selected), false, nil
 }
 
 func run(argv []string, stdout, stderr io.Writer) int {
@@ -667,10 +4548,25 @@ func run(argv []string, stdout, stderr io.Writer) int {
 	}
 	var pathOpt string
 	args, pathOpt = extractOption(args, "--path")
-	triesPath := defaultTryPath()
-	if pathOpt != "" {
-		triesPath = mustExpand(pathOpt)
+	var hardDelete, noGit bool
+	args, hardDelete = extractFlag(args, "--hard")
+	args, noGit = extractFlag(args, "--no-git")
+	loaded, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
 	}
+	cfg = loaded
+	applyTheme(cfg.Theme)
+	if roots := splitRoots(pathOpt); len(roots) > 0 {
+		cfg.Roots = roots
+		cfg.sources["path"] = "flag"
+	}
+	if noGit {
+		cfg.GitStatus = false
+		cfg.sources["git_status"] = "flag"
+	}
+	roots := cfg.Roots
 	if len(args) == 0 {
 		printHelp(stderr)
 		return 2
@@ -679,79 +4575,93 @@ func run(argv []string, stdout, stderr io.Writer) int {
 	command := args[0]
 	args = args[1:]
 
-	emit := func(cmds []string) {
+	finish := func(cmds []string, cancelled bool, err error) int {
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		if cancelled {
+			fmt.Fprintln(stdout, "Cancelled.")
+			return 1
+		}
 		emitScript(stdout, cmds)
+		return 0
+	}
+
+	if command == "exec" && len(args) > 0 && slices.Contains(directCommands, args[0]) {
+		command, args = args[0], args[1:]
 	}
 
 	switch command {
 	case "init":
 		exe, _ := os.Executable()
+		// Only pin the path into the wrapper when it was given explicitly, so
+		// later edits to TRY_PATH or the config file still take effect.
+		pinned := ""
+		if cfg.source("path") == "flag" {
+			pinned = strings.Join(roots, string(filepath.ListSeparator))
+		}
 		if len(args) > 0 && strings.HasPrefix(args[0], "/") {
-			triesPath = mustExpand(args[0])
+			pinned = mustExpand(args[0])
 		}
-		fmt.Fprint(stdout, initScript(exe, triesPath))
+		fmt.Fprint(stdout, initScript(exe, pinned))
 		return 0
-	case "clone":
-		cmds, err := cmdClone(args, triesPath)
-		if err != nil {
+	case "config":
+		if len(args) == 0 || args[0] != "show" {
+			fmt.Fprintln(stderr, "Error: usage: try config show")
+			return 1
+		}
+		if err := cfg.writeConfig(stdout); err != nil {
 			fmt.Fprintf(stderr, "Error: %v\n", err)
 			return 1
 		}
-		emit(cmds)
 		return 0
-	case "exec":
-		targetCommand := "cd"
-		if len(args) > 0 {
-			targetCommand = args[0]
-			args = args[1:]
-		}
-		switch targetCommand {
-		case "clone":
-			cmds, err := cmdClone(args, triesPath)
-			if err != nil {
-				fmt.Fprintf(stderr, "Error: %v\n", err)
-				return 1
-			}
-			emit(cmds)
-			return 0
-		case "cd":
-			cmds, cancelled, err := cmdCD(args, triesPath)
-			if err != nil {
-				fmt.Fprintf(stderr, "Error: %v\n", err)
-				return 1
-			}
-			if cancelled {
-				fmt.Fprintln(stdout, "Cancelled.")
-				return 1
-			}
-			emit(cmds)
-			return 0
-		default:
-			args = append([]string{targetCommand}, args...)
-			cmds, cancelled, err := cmdCD(args, triesPath)
-			if err != nil {
-				fmt.Fprintf(stderr, "Error: %v\n", err)
-				return 1
-			}
-			if cancelled {
-				fmt.Fprintln(stdout, "Cancelled.")
-				return 1
-			}
-			emit(cmds)
-			return 0
+	case "tag":
+		if err := cmdTag(args, roots, stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
 		}
-	default:
-		cmds, cancelled, err := cmdCD(append([]string{command}, args...), triesPath)
-		if err != nil {
+		return 0
+	case "describe":
+		if err := cmdDescribe(args, roots); err != nil {
 			fmt.Fprintf(stderr, "Error: %v\n", err)
 			return 1
 		}
-		if cancelled {
-			fmt.Fprintln(stdout, "Cancelled.")
+		return 0
+	case "pin", "unpin":
+		if err := cmdPin(args, roots, command == "pin", stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
 			return 1
 		}
-		emit(cmds)
 		return 0
+	case "cache":
+		if err := cmdCache(args, stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "du":
+		if err := cmdDu(args, roots, stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "list":
+		if err := cmdList(args, roots, stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "exec":
+		if len(args) == 0 || args[0] == "cd" {
+			if len(args) > 0 {
+				args = args[1:]
+			}
+			return finish(cmdCD(args, roots, hardDelete))
+		}
+		return finish(dispatch(args[0], args[1:], roots, hardDelete))
+	default:
+		return finish(dispatch(command, args, roots, hardDelete))
 	}
 }
 